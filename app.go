@@ -1,32 +1,89 @@
 package main
 
 import (
+	"errors"
 	"github.com/google/uuid"
 	"time"
 )
 
+// errConflict tells that a todo was changed by someone else since it was read
+var errConflict = errors.New("the todo was changed by someone else in the meantime, have a look at it and try again")
+
+// errRevisionMissing tells that a todo is to be overwritten without the revision it was read at
+var errRevisionMissing = errors.New("the revision the todo was read at must be provided")
+
 type app interface {
-	findAll() ([]todoModel, ShortIdMap)
-	findWhereDueBefore(due time.Time) ([]todoModel, ShortIdMap)
+	findAll(filter todoFilter) ([]todoModel, ShortIdMap)
+	findWhereDueBefore(due time.Time, filter todoFilter) ([]todoModel, ShortIdMap)
 	findToBeNotifiedByDueBefore(due time.Time) ([]todoModel, ShortIdMap)
+	findToBeRemindedAt(now time.Time) ([]todoModel, ShortIdMap)
 	find(searchFor string) (*todoModel, string)
-	add(title string, details string, due time.Time) error
+	findMatching(searchFor string) ([]todoModel, ShortIdMap)
+	add(entry todoModel) error
 	delete(todoId uuid.UUID) error
 	markNotified(todoId uuid.UUID) error
+	markReminded(todoId uuid.UUID, before string) error
 	setNewDue(todoId uuid.UUID, due time.Time) error
 	resolve(todoId uuid.UUID) error
+	setPriority(todoId uuid.UUID, priority string) error
+	update(entry todoModel) error
+	addChecklistItem(todoId uuid.UUID, text string) error
+	checkChecklistItem(todoId uuid.UUID, index int, done bool) error
+	removeChecklistItem(todoId uuid.UUID, index int) error
+	block(todoId uuid.UUID, blockerId uuid.UUID) error
+	unblock(todoId uuid.UUID, blockerId uuid.UUID) error
+	findArchived(resolvedFrom time.Time, resolvedTo time.Time) ([]todoModel, ShortIdMap)
+	findInArchive(searchFor string) (*todoModel, string)
+	reopen(todoId uuid.UUID) error
+	watch(lastEventId string, handle func(event changeEvent)) error
+	backup() (backupBundle, error)
+	restore(bundle backupBundle, mode restoreMode) (restoreResult, error)
 }
 
 type todoModel struct {
-	Title        string            `json:"title"`
-	Details      string            `json:"details"`
-	Due          time.Time         `json:"due"`
-	Id           uuid.UUID         `json:"id"`
-	Notification notificationModel `json:"notification"`
-	ResolvedAt   time.Time         `json:"resolvedAt"`
+	Title        string               `json:"title"`
+	Details      string               `json:"details"`
+	Due          time.Time            `json:"due"`
+	Id           uuid.UUID            `json:"id"`
+	Notification notificationModel    `json:"notification"`
+	ResolvedAt   time.Time            `json:"resolvedAt"`
+	Recurrence   *recurrenceModel     `json:"recurrence,omitempty"`
+	Tags         []string             `json:"tags,omitempty"`
+	Project      string               `json:"project,omitempty"`
+	Priority     string               `json:"priority,omitempty"`
+	Checklist    []checklistItemModel `json:"checklist,omitempty"`
+	AutoResolve  bool                 `json:"autoResolve,omitempty"`
+	BlockedBy    []uuid.UUID          `json:"blockedBy,omitempty"`
+	// Blocked tells whether one of the todos in BlockedBy is still active
+	Blocked   bool      `json:"blocked,omitempty"`
+	Revision  int       `json:"revision"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type checklistItemModel struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
 }
 
 type notificationModel struct {
-	Type       string    `json:"type"`
-	NotifiedAt time.Time `json:"notifiedAt"`
+	Type          string          `json:"type"`
+	NotifiedAt    time.Time       `json:"notifiedAt"`
+	History       []time.Time     `json:"history,omitempty"`
+	Interval      string          `json:"interval,omitempty"`
+	EscalateAfter int             `json:"escalateAfter,omitempty"`
+	Reminders     []reminderModel `json:"reminders,omitempty"`
+}
+
+type reminderModel struct {
+	Before string    `json:"before"`
+	SentAt time.Time `json:"sentAt"`
+}
+
+type recurrenceModel struct {
+	Frequency  string    `json:"frequency"`
+	Interval   int       `json:"interval,omitempty"`
+	Weekdays   []string  `json:"weekdays,omitempty"`
+	Until      time.Time `json:"until,omitempty"`
+	Count      int       `json:"count,omitempty"`
+	Occurrence int       `json:"occurrence,omitempty"`
 }
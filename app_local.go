@@ -1,40 +1,59 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"sort"
 	"strings"
 	"time"
 )
 
 type appLocal struct {
 	repo repository
+	// events receives every change, nil when nobody listens
+	events *eventBus
 }
 
-func (app *appLocal) findAll() ([]todoModel, ShortIdMap) {
-	return mapTodosWithIdMap(app.readAllEntriesAndBuildIdMapInternal())
+func (app *appLocal) findAll(filter todoFilter) ([]todoModel, ShortIdMap) {
+	todos, idMap := app.readAllEntriesAndBuildIdMapInternal()
+	activeIds := activeIdsOf(todos)
+
+	matching := make([]todo, 0)
+
+	for _, entry := range todos {
+		if filter.matches(entry.Tags, entry.Project) && (!filter.Ready || !isBlocked(entry, activeIds)) {
+			matching = append(matching, entry)
+		}
+	}
+
+	return queryActiveTodosWithIdMap(matching, activeIds, idMap, filter)
 }
 
-func (app *appLocal) findWhereDueBefore(due time.Time) ([]todoModel, ShortIdMap) {
+func (app *appLocal) findWhereDueBefore(due time.Time, filter todoFilter) ([]todoModel, ShortIdMap) {
 	todos, idMap := app.readAllEntriesAndBuildIdMapInternal()
+	activeIds := activeIdsOf(todos)
 
 	matching := make([]todo, 0)
 
 	for _, entry := range todos {
-		if entry.Due.Before(due) {
+		if entry.Due.Before(due) && filter.matches(entry.Tags, entry.Project) && (!filter.Ready || !isBlocked(entry, activeIds)) {
 			matching = append(matching, entry)
 		}
 	}
 
-	return mapTodosWithIdMap(matching, idMap)
+	return queryActiveTodosWithIdMap(matching, activeIds, idMap, filter)
 }
 
 func (app *appLocal) findToBeNotifiedByDueBefore(due time.Time) ([]todoModel, ShortIdMap) {
 	todos, idMap := app.readAllEntriesAndBuildIdMapInternal()
+	activeIds := activeIdsOf(todos)
 
 	matching := make([]todo, 0)
 
 	for _, entry := range todos {
-		if entry.Due.Before(due) && entry.Notification.Type == NotificationTypeOnce && entry.Notification.NotifiedAt.IsZero() {
+		if entry.Notification.isDue(entry.Due, due) && !isBlocked(entry, activeIds) {
 			matching = append(matching, entry)
 		}
 	}
@@ -42,46 +61,69 @@ func (app *appLocal) findToBeNotifiedByDueBefore(due time.Time) ([]todoModel, Sh
 	return mapTodosWithIdMap(matching, idMap)
 }
 
-func (app *appLocal) find(searchFor string) (*todoModel, string) {
+func (app *appLocal) findToBeRemindedAt(now time.Time) ([]todoModel, ShortIdMap) {
 	todos, idMap := app.readAllEntriesAndBuildIdMapInternal()
+	activeIds := activeIdsOf(todos)
 
-	var matching *todo
+	matching := make([]todo, 0)
 
-	if matching == nil {
-		var todoId string
-		for original, short := range idMap {
-			if strings.EqualFold(strings.ToUpper(short), strings.ToUpper(searchFor)) {
-				todoId = original
-				break
-			}
+	for _, entry := range todos {
+		if entry.Notification.Type == NotificationTypeNone || isBlocked(entry, activeIds) {
+			continue
 		}
-		if len(todoId) > 0 {
-			for _, entry := range todos {
-				if strings.EqualFold(entry.Id.String(), todoId) {
-					matching = &entry
-					break
-				}
+		for _, r := range entry.Notification.Reminders {
+			if r.isDue(entry.Due, now) {
+				matching = append(matching, entry)
+				break
 			}
 		}
 	}
 
-	if matching == nil {
-		for _, entry := range todos {
-			if strings.Contains(strings.ToUpper(entry.Id.String()), strings.ToUpper(searchFor)) {
-				matching = &entry
-				break
-			}
-		}
+	return mapTodosWithIdMap(matching, idMap)
+}
+
+func (app *appLocal) find(searchFor string) (*todoModel, string) {
+	todos, idMap := app.readAllEntriesAndBuildIdMapInternal()
+
+	matching := findMatchingInternal(todos, idMap, searchFor)
+
+	shortId := ""
+	if matching != nil {
+		shortId = idMap[matching.Id.String()]
 	}
-	if matching == nil {
-		for _, entry := range todos {
-			if strings.Contains(strings.ToUpper(entry.Title), strings.ToUpper(searchFor)) {
-				matching = &entry
-				break
-			}
+
+	model, shortId := mapTodoWithShortId(matching, shortId)
+	if model != nil {
+		model.Blocked = isBlocked(*matching, activeIdsOf(todos))
+	}
+	return model, shortId
+}
+
+func (app *appLocal) findMatching(searchFor string) ([]todoModel, ShortIdMap) {
+	todos, idMap := app.readAllEntriesAndBuildIdMapInternal()
+
+	return mapActiveTodosWithIdMap(findAllMatchingInternal(todos, idMap, searchFor), activeIdsOf(todos), idMap)
+}
+
+func (app *appLocal) findArchived(resolvedFrom time.Time, resolvedTo time.Time) ([]todoModel, ShortIdMap) {
+	todos, idMap := app.readArchivedEntriesAndBuildIdMapInternal()
+
+	matching := make([]todo, 0)
+
+	for _, entry := range todos {
+		if !entry.ResolvedAt.Before(resolvedFrom) && (resolvedTo.IsZero() || entry.ResolvedAt.Before(resolvedTo)) {
+			matching = append(matching, entry)
 		}
 	}
 
+	return mapTodosWithIdMap(matching, idMap)
+}
+
+func (app *appLocal) findInArchive(searchFor string) (*todoModel, string) {
+	todos, idMap := app.readArchivedEntriesAndBuildIdMapInternal()
+
+	matching := findMatchingInternal(todos, idMap, searchFor)
+
 	shortId := ""
 	if matching != nil {
 		shortId = idMap[matching.Id.String()]
@@ -90,9 +132,69 @@ func (app *appLocal) find(searchFor string) (*todoModel, string) {
 	return mapTodoWithShortId(matching, shortId)
 }
 
-func (app *appLocal) add(title string, details string, due time.Time) error {
-	todo := todo{Title: title, Details: details, Id: uuid.New(), Due: due, Notification: notification{Type: NotificationTypeOnce}}
-	return app.repo.insertEntry(todo)
+// findMatchingInternal returns the best matching todo, nil if none matches
+func findMatchingInternal(todos []todo, idMap ShortIdMap, searchFor string) *todo {
+	matching := findAllMatchingInternal(todos, idMap, searchFor)
+	if len(matching) == 0 {
+		return nil
+	}
+	return &matching[0]
+}
+
+// findAllMatchingInternal returns every todo matching, ranked by rankMatch and keeping the order of the todos within
+// a rank
+func findAllMatchingInternal(todos []todo, idMap ShortIdMap, searchFor string) []todo {
+	matching := make([]todo, 0)
+	ranks := make(map[uuid.UUID]matchRank)
+	for _, entry := range todos {
+		rank := rankMatch(entry.Id, entry.Title, idMap[entry.Id.String()], searchFor)
+		if rank != MatchRankNone {
+			matching = append(matching, entry)
+			ranks[entry.Id] = rank
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return ranks[matching[i].Id] < ranks[matching[j].Id]
+	})
+	return matching
+}
+
+func (app *appLocal) add(entry todoModel) error {
+	reminders, err := parseReminders(leadTimesOf(entry.Notification.Reminders))
+	if err != nil {
+		return err
+	}
+	todo := todo{Title: entry.Title, Details: entry.Details, Id: uuid.New(), Revision: 1, CreatedAt: entry.CreatedAt, Due: entry.Due, Notification: notification{Type: NotificationTypeOnce, Reminders: reminders}, Recurrence: mapRecurrenceModel(entry.Recurrence), Tags: entry.Tags, Project: entry.Project, Priority: priority(entry.Priority), Checklist: mapChecklistModel(entry.Checklist), AutoResolve: entry.AutoResolve}
+	if todo.CreatedAt.IsZero() {
+		todo.CreatedAt = time.Now()
+	}
+	if len(entry.Notification.Type) > 0 {
+		err = applyNotificationSetting(&todo.Notification, entry.Notification)
+		if err != nil {
+			return err
+		}
+	}
+	err = todo.validate()
+	if err != nil {
+		return err
+	}
+	err = app.repo.insertEntry(todo)
+	if err != nil {
+		return err
+	}
+	app.events.publish(ChangeTypeCreated, todo)
+	if !entry.ResolvedAt.IsZero() {
+		// an entry resolved already, like an imported one, goes straight into the archive
+		todo, err = app.repo.readEntryById(todo.Id)
+		if err != nil {
+			return err
+		}
+		todo.ResolvedAt = entry.ResolvedAt
+		app.updateEntryInternal(&todo)
+		app.repo.archiveEntry(todo)
+		app.events.publish(ChangeTypeResolved, todo)
+	}
+	return nil
 }
 
 func (app *appLocal) delete(todoId uuid.UUID) error {
@@ -101,6 +203,7 @@ func (app *appLocal) delete(todoId uuid.UUID) error {
 		return err
 	}
 	app.repo.deleteEntry(todo)
+	app.events.publish(ChangeTypeDeleted, todo)
 	return nil
 }
 
@@ -109,8 +212,30 @@ func (app *appLocal) markNotified(todoId uuid.UUID) error {
 	if err != nil {
 		return err
 	}
-	todo.Notification.NotifiedAt = time.Now()
-	app.repo.updateEntry(todo)
+	todo.Notification.History = append(todo.Notification.History, time.Now())
+	app.updateEntryInternal(&todo)
+	app.events.publish(ChangeTypeNotified, todo)
+	return nil
+}
+
+// markReminded marks the reminder with the given lead time as sent, together with all reminders of a longer lead time
+func (app *appLocal) markReminded(todoId uuid.UUID, before string) error {
+	todo, err := app.repo.readEntryById(todoId)
+	if err != nil {
+		return err
+	}
+	leadTime, err := parseLeadTime(before)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range todo.Notification.Reminders {
+		if todo.Notification.Reminders[i].Before >= leadTime && todo.Notification.Reminders[i].SentAt.IsZero() {
+			todo.Notification.Reminders[i].SentAt = now
+		}
+	}
+	app.updateEntryInternal(&todo)
+	app.events.publish(ChangeTypeNotified, todo)
 	return nil
 }
 
@@ -120,8 +245,9 @@ func (app *appLocal) setNewDue(todoId uuid.UUID, due time.Time) error {
 		return err
 	}
 	todo.Due = due
-	todo.Notification.NotifiedAt = time.Time{}
-	app.repo.updateEntry(todo)
+	todo.Notification.reset()
+	app.updateEntryInternal(&todo)
+	app.events.publish(ChangeTypeUpdated, todo)
 	return nil
 }
 
@@ -131,11 +257,263 @@ func (app *appLocal) resolve(todoId uuid.UUID) error {
 		return err
 	}
 	todo.ResolvedAt = time.Now()
-	app.repo.updateEntry(todo)
+	app.updateEntryInternal(&todo)
 	app.repo.archiveEntry(todo)
+	app.events.publish(ChangeTypeResolved, todo)
+	if todo.Recurrence != nil {
+		return app.insertNextOccurrenceInternal(todo)
+	}
 	return nil
 }
 
+func (app *appLocal) insertNextOccurrenceInternal(resolved todo) error {
+	recurrence := *resolved.Recurrence
+	due := resolved.Due
+	for {
+		next, ok := recurrence.next(due)
+		if !ok {
+			return nil
+		}
+		// occurrences skipped by resolving late count as well, not to exceed the count of the rule
+		due = next
+		recurrence.Occurrence = recurrence.occurrence() + 1
+		if due.After(resolved.ResolvedAt) {
+			break
+		}
+	}
+	nextTodo := todo{Title: resolved.Title, Details: resolved.Details, Id: uuid.New(), Revision: 1, CreatedAt: time.Now(), Due: due, Notification: notification{Type: resolved.Notification.Type, Interval: resolved.Notification.Interval, EscalateAfter: resolved.Notification.EscalateAfter, Reminders: resetReminders(resolved.Notification.Reminders)}, Recurrence: &recurrence, Tags: resolved.Tags, Project: resolved.Project, Priority: resolved.Priority, AutoResolve: resolved.AutoResolve}
+	for _, item := range resolved.Checklist {
+		nextTodo.Checklist = append(nextTodo.Checklist, checklistItem{Text: item.Text})
+	}
+	err := app.repo.insertEntry(nextTodo)
+	if err != nil {
+		return err
+	}
+	app.events.publish(ChangeTypeCreated, nextTodo)
+	return nil
+}
+
+func (app *appLocal) setPriority(todoId uuid.UUID, priority string) error {
+	todo, err := app.repo.readEntryById(todoId)
+	if err != nil {
+		return err
+	}
+	parsedPriority, err := parsePriority(priority)
+	if err != nil {
+		return err
+	}
+	todo.Priority = parsedPriority
+	app.updateEntryInternal(&todo)
+	app.events.publish(ChangeTypeUpdated, todo)
+	return nil
+}
+
+// update overwrites the todo with the entry, failing with errConflict if the entry has a revision other than the todo
+func (app *appLocal) update(entry todoModel) error {
+	todo, err := app.repo.readEntryById(entry.Id)
+	if err != nil {
+		return err
+	}
+	if entry.Revision == 0 {
+		return errRevisionMissing
+	}
+	if entry.Revision != todo.Revision {
+		return errConflict
+	}
+	if len(entry.Title) == 0 {
+		return errors.New("a title must be provided")
+	}
+	if entry.Due.IsZero() {
+		return errors.New("a due date must be provided")
+	}
+	todo.Title = entry.Title
+	todo.Details = entry.Details
+	if !todo.Due.Equal(entry.Due) {
+		todo.Due = entry.Due
+		todo.Notification.reset()
+	}
+	if len(entry.Notification.Type) > 0 {
+		err = applyNotificationSetting(&todo.Notification, entry.Notification)
+		if err != nil {
+			return err
+		}
+	}
+	if entry.Notification.Reminders != nil {
+		reminders, err := parseReminders(leadTimesOf(entry.Notification.Reminders))
+		if err != nil {
+			return err
+		}
+		for i := range reminders {
+			for _, current := range todo.Notification.Reminders {
+				if current.Before == reminders[i].Before {
+					reminders[i].SentAt = current.SentAt
+				}
+			}
+		}
+		todo.Notification.Reminders = reminders
+	}
+	if entry.Checklist != nil {
+		todo.Checklist = mapChecklistModel(entry.Checklist)
+	}
+	todo.AutoResolve = entry.AutoResolve
+	err = todo.validate()
+	if err != nil {
+		return err
+	}
+	app.updateEntryInternal(&todo)
+	app.events.publish(ChangeTypeUpdated, todo)
+	return nil
+}
+
+func (app *appLocal) addChecklistItem(todoId uuid.UUID, text string) error {
+	todo, err := app.repo.readEntryById(todoId)
+	if err != nil {
+		return err
+	}
+	text = strings.TrimSpace(text)
+	if len(text) == 0 {
+		return errors.New("a checklist item needs a text")
+	}
+	todo.Checklist = append(todo.Checklist, checklistItem{Text: text})
+	app.updateEntryInternal(&todo)
+	app.events.publish(ChangeTypeUpdated, todo)
+	return nil
+}
+
+// checkChecklistItem marks the item at the 1-based index as done or not, resolving the todo when it
+// auto-resolves and every item is done
+func (app *appLocal) checkChecklistItem(todoId uuid.UUID, index int, done bool) error {
+	todo, err := app.repo.readEntryById(todoId)
+	if err != nil {
+		return err
+	}
+	position, err := checklistIndex(todo.Checklist, index)
+	if err != nil {
+		return err
+	}
+	todo.Checklist[position].Done = done
+	app.updateEntryInternal(&todo)
+	app.events.publish(ChangeTypeUpdated, todo)
+	if done && todo.AutoResolve && isChecklistComplete(todo.Checklist) {
+		return app.resolve(todoId)
+	}
+	return nil
+}
+
+func (app *appLocal) removeChecklistItem(todoId uuid.UUID, index int) error {
+	todo, err := app.repo.readEntryById(todoId)
+	if err != nil {
+		return err
+	}
+	position, err := checklistIndex(todo.Checklist, index)
+	if err != nil {
+		return err
+	}
+	todo.Checklist = append(todo.Checklist[:position], todo.Checklist[position+1:]...)
+	app.updateEntryInternal(&todo)
+	app.events.publish(ChangeTypeUpdated, todo)
+	return nil
+}
+
+func (app *appLocal) block(todoId uuid.UUID, blockerId uuid.UUID) error {
+	todos := app.repo.readAllEntries()
+	todo, err := app.repo.readEntryById(todoId)
+	if err != nil {
+		return err
+	}
+	if !activeIdsOf(todos)[blockerId] {
+		return errors.New("no active todo present with id " + blockerId.String())
+	}
+	for _, present := range todo.BlockedBy {
+		if present == blockerId {
+			return nil
+		}
+	}
+	err = checkBlockable(todos, todoId, blockerId)
+	if err != nil {
+		return err
+	}
+	todo.BlockedBy = append(todo.BlockedBy, blockerId)
+	app.updateEntryInternal(&todo)
+	app.events.publish(ChangeTypeUpdated, todo)
+	return nil
+}
+
+func (app *appLocal) unblock(todoId uuid.UUID, blockerId uuid.UUID) error {
+	todo, err := app.repo.readEntryById(todoId)
+	if err != nil {
+		return err
+	}
+	remaining := make([]uuid.UUID, 0, len(todo.BlockedBy))
+	for _, present := range todo.BlockedBy {
+		if present != blockerId {
+			remaining = append(remaining, present)
+		}
+	}
+	if len(remaining) == len(todo.BlockedBy) {
+		return errors.New(fmt.Sprintf("%s is not blocked by %s", todoId, blockerId))
+	}
+	todo.BlockedBy = remaining
+	app.updateEntryInternal(&todo)
+	app.events.publish(ChangeTypeUpdated, todo)
+	return nil
+}
+
+// reopen moves a resolved todo back to the active ones. Reopening an occurrence of a recurring todo takes the place of
+// the following occurrence created on resolving it, as both share the title.
+func (app *appLocal) reopen(todoId uuid.UUID) error {
+	todo, err := app.repo.readArchivedEntryById(todoId)
+	if err != nil {
+		return err
+	}
+	for _, active := range app.repo.readAllEntries() {
+		if active.Title != todo.Title {
+			continue
+		}
+		if todo.Recurrence == nil || active.Recurrence == nil || active.Recurrence.occurrence() <= todo.Recurrence.occurrence() {
+			return fmt.Errorf("the active todo %s is titled %s as well, rename or resolve it before reopening this one", active.Id, active.Title)
+		}
+		app.repo.deleteEntry(active)
+		app.events.publish(ChangeTypeDeleted, active)
+	}
+	todo.ResolvedAt = time.Time{}
+	todo.Revision++
+	err = app.repo.unarchiveEntry(todo)
+	if err != nil {
+		return err
+	}
+	app.events.publish(ChangeTypeUpdated, todo)
+	return nil
+}
+
+// updateEntryInternal writes the todo as its next revision
+func (app *appLocal) updateEntryInternal(todo *todo) {
+	todo.Revision++
+	app.repo.updateEntry(*todo)
+}
+
+// watch hands the events after lastEventId, if not empty, and all events to come to handle, as long as the app has an event bus
+func (app *appLocal) watch(lastEventId string, handle func(event changeEvent)) error {
+	if app.events == nil {
+		return errors.New("no events to watch, as changes are only streamed by the server")
+	}
+	replay, subscriber := app.events.subscribe(lastEventId)
+	defer app.events.unsubscribe(subscriber)
+	for _, event := range replay {
+		handle(event)
+	}
+	for event := range subscriber {
+		handle(event)
+	}
+	return nil
+}
+
+func (app *appLocal) readArchivedEntriesAndBuildIdMapInternal() ([]todo, ShortIdMap) {
+	entries := app.repo.readArchivedEntries()
+	idMap := CreateIdMap(entries)
+	return entries, idMap
+}
+
 func (app *appLocal) readAllEntriesAndBuildIdMapInternal() ([]todo, ShortIdMap) {
 	entries := app.repo.readAllEntries()
 	idMap := CreateIdMap(entries)
@@ -146,6 +524,26 @@ func mapTodosWithIdMap(todos []todo, shortIdMap ShortIdMap) ([]todoModel, ShortI
 	return mapTodos(todos), shortIdMap
 }
 
+func mapActiveTodosWithIdMap(todos []todo, activeIds map[uuid.UUID]bool, shortIdMap ShortIdMap) ([]todoModel, ShortIdMap) {
+	models := mapTodos(todos)
+	for i := range models {
+		models[i].Blocked = isBlocked(todos[i], activeIds)
+	}
+	return models, shortIdMap
+}
+
+// queryActiveTodosWithIdMap keeps the todos matching the filter expression, evaluated on the models to know which
+// todos are blocked. An invalid expression matches nothing, callers are to check it with parseTodoQuery beforehand.
+func queryActiveTodosWithIdMap(todos []todo, activeIds map[uuid.UUID]bool, shortIdMap ShortIdMap, filter todoFilter) ([]todoModel, ShortIdMap) {
+	models, shortIdMap := mapActiveTodosWithIdMap(todos, activeIds, shortIdMap)
+	matching, err := filterByQuery(models, filter.Query, time.Now(), filter.location())
+	if err != nil {
+		log.Errorf("Invalid query '%s': %v", filter.Query, err)
+		return make([]todoModel, 0), shortIdMap
+	}
+	return matching, shortIdMap
+}
+
 func mapTodoWithShortId(todo *todo, shortId string) (*todoModel, string) {
 	if todo == nil {
 		return nil, ""
@@ -170,22 +568,124 @@ func mapTodo(todo todo) todoModel {
 		Id:           todo.Id,
 		Notification: mapNotification(todo.Notification),
 		ResolvedAt:   todo.ResolvedAt,
+		Recurrence:   mapRecurrence(todo.Recurrence),
+		Tags:         todo.Tags,
+		Project:      todo.Project,
+		Priority:     string(todo.Priority),
+		Checklist:    mapChecklist(todo.Checklist),
+		AutoResolve:  todo.AutoResolve,
+		BlockedBy:    todo.BlockedBy,
+		Revision:     todo.Revision,
+		CreatedAt:    todo.CreatedAt,
 	}
 }
 
+// mapTodoModel is the reverse of mapTodo, restoring a todo exactly as it was, notification state included
+func mapTodoModel(model todoModel) (todo, error) {
+	var interval time.Duration
+	var err error
+	if len(model.Notification.Interval) > 0 {
+		interval, err = parseLeadTime(model.Notification.Interval)
+		if err != nil {
+			return todo{}, err
+		}
+	}
+	reminders := make([]reminder, 0, len(model.Notification.Reminders))
+	for _, reminderModel := range model.Notification.Reminders {
+		before, err := parseLeadTime(reminderModel.Before)
+		if err != nil {
+			return todo{}, err
+		}
+		reminders = append(reminders, reminder{Before: before, SentAt: reminderModel.SentAt})
+	}
+	if len(reminders) == 0 {
+		reminders = nil
+	}
+	entry := todo{
+		Title:        model.Title,
+		Details:      model.Details,
+		Due:          model.Due,
+		Id:           model.Id,
+		Notification: notification{Type: notificationType(model.Notification.Type), NotifiedAt: model.Notification.NotifiedAt, History: model.Notification.History, Interval: interval, EscalateAfter: model.Notification.EscalateAfter, Reminders: reminders},
+		ResolvedAt:   model.ResolvedAt,
+		Recurrence:   mapRecurrenceModel(model.Recurrence),
+		Tags:         model.Tags,
+		Project:      model.Project,
+		Priority:     priority(model.Priority),
+		Checklist:    mapChecklistModel(model.Checklist),
+		AutoResolve:  model.AutoResolve,
+		BlockedBy:    model.BlockedBy,
+		Revision:     model.Revision,
+		CreatedAt:    model.CreatedAt,
+	}
+	return entry, entry.validate()
+}
+
 func mapNotification(notification notification) notificationModel {
 	return notificationModel{
-		Type:       mapNotificationType(notification.Type),
-		NotifiedAt: notification.NotifiedAt,
+		Type:          mapNotificationType(notification.Type),
+		NotifiedAt:    notification.lastNotifiedAt(),
+		History:       notification.History,
+		Interval:      mapInterval(notification.Interval),
+		EscalateAfter: notification.EscalateAfter,
+		Reminders:     mapReminders(notification.Reminders),
 	}
 }
 
+func mapInterval(interval time.Duration) string {
+	if interval <= 0 {
+		return ""
+	}
+	return formatLeadTime(interval)
+}
+
+func mapReminders(reminders []reminder) []reminderModel {
+	if reminders == nil {
+		return nil
+	}
+	res := make([]reminderModel, 0, len(reminders))
+	for _, r := range reminders {
+		res = append(res, reminderModel{Before: formatLeadTime(r.Before), SentAt: r.SentAt})
+	}
+	return res
+}
+
 func mapNotificationType(notificationType notificationType) string {
 	switch notificationType {
 	case NotificationTypeNone:
 		return "none"
 	case NotificationTypeOnce:
 		return "once"
+	case NotificationTypeRepeat:
+		return "repeat"
 	}
 	return ""
 }
+
+func mapRecurrence(recurrence *recurrence) *recurrenceModel {
+	if recurrence == nil {
+		return nil
+	}
+	return &recurrenceModel{
+		Frequency:  string(recurrence.Frequency),
+		Interval:   recurrence.Interval,
+		Weekdays:   recurrence.Weekdays,
+		Until:      recurrence.Until,
+		Count:      recurrence.Count,
+		Occurrence: recurrence.Occurrence,
+	}
+}
+
+func mapRecurrenceModel(recurrenceModel *recurrenceModel) *recurrence {
+	if recurrenceModel == nil {
+		return nil
+	}
+	return &recurrence{
+		Frequency:  frequency(recurrenceModel.Frequency),
+		Interval:   recurrenceModel.Interval,
+		Weekdays:   recurrenceModel.Weekdays,
+		Until:      recurrenceModel.Until,
+		Count:      recurrenceModel.Count,
+		Occurrence: recurrenceModel.Occurrence,
+	}
+}
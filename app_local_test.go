@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestApp returns an app on a todo directory of its own, storing the todos as yaml files like by default
+func newTestApp(t *testing.T) *appLocal {
+	return &appLocal{repo: newRepositoryFs(config{TodoDir: t.TempDir()})}
+}
+
+func TestAppLocal_reopen(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "call bank", Due: time.Now()}) == nil)
+	entry, _ := app.find("call bank")
+	assertTrue(t, app.resolve(entry.Id) == nil)
+
+	archived, _ := app.findInArchive("call bank")
+	assertTrue(t, archived != nil)
+	assertTrue(t, app.reopen(archived.Id) == nil)
+	reopened, _ := app.find("call bank")
+	assertTrue(t, reopened != nil)
+	assertTrue(t, reopened.ResolvedAt.IsZero())
+	resolved, _ := app.findArchived(time.Time{}, time.Time{})
+	assertTrue(t, len(resolved) == 0)
+}
+
+func TestAppLocal_reopenRecurringReplacesNextOccurrence(t *testing.T) {
+	app := newTestApp(t)
+	due := time.Now().Add(time.Hour)
+	assertTrue(t, app.add(todoModel{Title: "standup", Due: due, Recurrence: &recurrenceModel{Frequency: "daily"}}) == nil)
+	entry, _ := app.find("standup")
+	assertTrue(t, app.resolve(entry.Id) == nil)
+
+	assertTrue(t, app.reopen(entry.Id) == nil)
+	active, _ := app.findAll(todoFilter{})
+	assertTrue(t, len(active) == 1)
+	assertEquals(t, entry.Id.String(), active[0].Id.String())
+	assertTrue(t, active[0].Due.Equal(entry.Due))
+}
+
+func TestAppLocal_reopenRefusesTitleTaken(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "call bank", Due: time.Now()}) == nil)
+	entry, _ := app.find("call bank")
+	assertTrue(t, app.resolve(entry.Id) == nil)
+	assertTrue(t, app.add(todoModel{Title: "call bank", Due: time.Now()}) == nil)
+
+	err := app.reopen(entry.Id)
+	assertTrue(t, err != nil)
+	assertTrue(t, strings.Contains(err.Error(), "rename or resolve it"))
+	active, _ := app.findAll(todoFilter{})
+	assertTrue(t, len(active) == 1)
+}
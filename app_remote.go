@@ -1,36 +1,60 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
+	"net/url"
 	"time"
 )
 
 type appRemote struct {
 	restClient *restClient
+	// revisions holds the revision of each todo as last seen, sent along with every change of the todo
+	revisions map[uuid.UUID]int
 }
 
 func newAppRemote(restClient *restClient) *appRemote {
-	return &appRemote{restClient: restClient}
+	return &appRemote{restClient: restClient, revisions: make(map[uuid.UUID]int)}
 }
 
-func (app appRemote) findAll() ([]todoModel, ShortIdMap) {
+func (app appRemote) findAll(filter todoFilter) ([]todoModel, ShortIdMap) {
 	response := TodosResponse{}
-	err := app.restClient.doGet("/todos", &response)
+	query := url.Values{}
+	for _, tag := range filter.Tags {
+		query.Add("tag", tag)
+	}
+	if len(filter.Project) > 0 {
+		query.Set("project", filter.Project)
+	}
+	if filter.Ready {
+		query.Set("ready", "true")
+	}
+	if len(filter.Query) > 0 {
+		query.Set("query", filter.Query)
+		query.Set("location", formatQueryLocation(filter.Location))
+	}
+	path := "/todos"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	err := app.restClient.doGet(path, &response)
 	if err != nil {
 		log.Errorf("Error requesting all todos: %v\n", err)
 	}
+	app.rememberRevisions(response.Todos)
 	return response.Todos, response.ShortIdMap
 }
 
-func (app appRemote) findWhereDueBefore(due time.Time) ([]todoModel, ShortIdMap) {
+func (app appRemote) findWhereDueBefore(due time.Time, filter todoFilter) ([]todoModel, ShortIdMap) {
 	response := TodosResponse{}
-	searchParams := SearchBody{DueBefore: due}
+	searchParams := SearchBody{DueBefore: due, Tags: filter.Tags, Project: filter.Project, Ready: filter.Ready, Query: filter.Query, Location: formatQueryLocation(filter.Location)}
 	err := app.restClient.doPost("/search", searchParams, &response)
 	if err != nil {
 		log.Errorf("Error finding a todo before '%s': %v\n", due, err)
 	}
+	app.rememberRevisions(response.Todos)
 	return response.Todos, response.ShortIdMap
 }
 
@@ -41,68 +65,357 @@ func (app appRemote) findToBeNotifiedByDueBefore(due time.Time) ([]todoModel, Sh
 	if err != nil {
 		log.Errorf("Error finding a todo to be notified before '%s': %v\n", due, err)
 	}
+	app.rememberRevisions(response.Todos)
 	return response.Todos, response.ShortIdMap
 }
 
-func (app appRemote) find(searchFor string) (*todoModel, string) {
+func (app appRemote) findToBeRemindedAt(now time.Time) ([]todoModel, ShortIdMap) {
 	response := TodosResponse{}
-	searchParams := SearchBody{SearchFor: searchFor}
+	searchParams := SearchBody{RemindedAt: now}
 	err := app.restClient.doPost("/search", searchParams, &response)
 	if err != nil {
-		log.Errorf("Error finding a todo for '%s': %v\n", searchFor, err)
+		log.Errorf("Error finding a todo to be reminded at '%s': %v\n", now, err)
 	}
+	app.rememberRevisions(response.Todos)
+	return response.Todos, response.ShortIdMap
+}
+
+func (app appRemote) find(searchFor string) (*todoModel, string) {
+	todos, shortIdMap := app.findMatching(searchFor)
 	var responseTodo *todoModel
 	responseShortId := ""
-	if len(response.Todos) > 0 {
-		responseTodo = &response.Todos[0]
-		responseShortId = response.ShortIdMap[response.Todos[0].Id.String()]
+	if len(todos) > 0 {
+		responseTodo = &todos[0]
+		responseShortId = shortIdMap[todos[0].Id.String()]
 	}
 	return responseTodo, responseShortId
 }
 
-func (app appRemote) add(title string, details string, due time.Time) error {
-	addParams := AddBody{Title: title, Details: details, Due: due}
+func (app appRemote) findMatching(searchFor string) ([]todoModel, ShortIdMap) {
+	response := TodosResponse{}
+	searchParams := SearchBody{SearchFor: searchFor}
+	err := app.restClient.doPost("/search", searchParams, &response)
+	if err != nil {
+		log.Errorf("Error finding a todo for '%s': %v\n", searchFor, err)
+	}
+	app.rememberRevisions(response.Todos)
+	return response.Todos, response.ShortIdMap
+}
+
+func (app appRemote) add(entry todoModel) error {
+	addParams := AddBody{Title: entry.Title, Details: entry.Details, Due: entry.Due, Recurrence: entry.Recurrence, Tags: entry.Tags, Project: entry.Project, Priority: entry.Priority, Reminders: leadTimesOf(entry.Notification.Reminders),
+		NotificationType: entry.Notification.Type, NotificationInterval: entry.Notification.Interval, EscalateAfter: entry.Notification.EscalateAfter, Checklist: entry.Checklist, AutoResolve: entry.AutoResolve,
+		CreatedAt: entry.CreatedAt, ResolvedAt: entry.ResolvedAt}
 	err := app.restClient.doPost("/todos", addParams, nil)
 	if err != nil {
-		log.Errorf("Error posting a new todo with title '%s': %v\n", title, err)
+		log.Errorf("Error posting a new todo with title '%s': %v\n", entry.Title, err)
 		return err
 	}
 	return nil
 }
 
 func (app appRemote) delete(todoId uuid.UUID) error {
-	err := app.restClient.doDelete(fmt.Sprintf("/todos/%s", todoId))
+	revision, err := app.revisionOf(todoId)
+	if err != nil {
+		return err
+	}
+	err = app.restClient.doDelete(fmt.Sprintf("/todos/%s", todoId), ifMatch(revision))
 	if err != nil {
 		log.Errorf("Error deleting a todo with the id '%s': %v\n", todoId, err)
 		return err
 	}
+	delete(app.revisions, todoId)
 	return nil
 }
 
 func (app appRemote) markNotified(todoId uuid.UUID) error {
-	err := app.restClient.doPost(fmt.Sprintf("/todos/%s/notified", todoId), nil, nil)
+	revision, err := app.revisionOf(todoId)
+	if err != nil {
+		return err
+	}
+	err = app.restClient.doPost(fmt.Sprintf("/todos/%s/notified", todoId), nil, nil, ifMatch(revision))
 	if err != nil {
 		log.Errorf("Error posting a todo as notified with the id '%s': %v\n", todoId, err)
 		return err
 	}
+	delete(app.revisions, todoId)
+	return nil
+}
+
+func (app appRemote) markReminded(todoId uuid.UUID, before string) error {
+	remindedParams := RemindedBody{Before: before}
+	revision, err := app.revisionOf(todoId)
+	if err != nil {
+		return err
+	}
+	err = app.restClient.doPost(fmt.Sprintf("/todos/%s/reminded", todoId), remindedParams, nil, ifMatch(revision))
+	if err != nil {
+		log.Errorf("Error posting a todo as reminded with the id '%s': %v\n", todoId, err)
+		return err
+	}
+	delete(app.revisions, todoId)
 	return nil
 }
 
 func (app appRemote) setNewDue(todoId uuid.UUID, due time.Time) error {
 	dueParams := DueBody{Due: due}
-	err := app.restClient.doPost(fmt.Sprintf("/todos/%s/due", todoId), dueParams, nil)
+	revision, err := app.revisionOf(todoId)
+	if err != nil {
+		return err
+	}
+	err = app.restClient.doPost(fmt.Sprintf("/todos/%s/due", todoId), dueParams, nil, ifMatch(revision))
 	if err != nil {
 		log.Errorf("Error posting a new due for a todo with the id '%s': %v\n", todoId, err)
 		return err
 	}
+	delete(app.revisions, todoId)
 	return nil
 }
 
 func (app appRemote) resolve(todoId uuid.UUID) error {
-	err := app.restClient.doPost(fmt.Sprintf("/todos/%s/resolved", todoId), nil, nil)
+	revision, err := app.revisionOf(todoId)
+	if err != nil {
+		return err
+	}
+	err = app.restClient.doPost(fmt.Sprintf("/todos/%s/resolved", todoId), nil, nil, ifMatch(revision))
 	if err != nil {
 		log.Errorf("Error posting a todo as resolved with the id '%s': %v\n", todoId, err)
 		return err
 	}
+	delete(app.revisions, todoId)
+	return nil
+}
+
+func (app appRemote) setPriority(todoId uuid.UUID, priority string) error {
+	priorityParams := PriorityBody{Priority: priority}
+	revision, err := app.revisionOf(todoId)
+	if err != nil {
+		return err
+	}
+	err = app.restClient.doPost(fmt.Sprintf("/todos/%s/priority", todoId), priorityParams, nil, ifMatch(revision))
+	if err != nil {
+		log.Errorf("Error posting a new priority for a todo with the id '%s': %v\n", todoId, err)
+		return err
+	}
+	delete(app.revisions, todoId)
 	return nil
 }
+
+func (app appRemote) update(entry todoModel) error {
+	updateParams := UpdateBody{Title: &entry.Title, Details: &entry.Details, Due: &entry.Due, NotificationType: &entry.Notification.Type,
+		NotificationInterval: &entry.Notification.Interval, EscalateAfter: &entry.Notification.EscalateAfter, AutoResolve: &entry.AutoResolve}
+	if entry.Notification.Reminders != nil {
+		reminders := leadTimesOf(entry.Notification.Reminders)
+		updateParams.Reminders = &reminders
+	}
+	if entry.Checklist != nil {
+		updateParams.Checklist = &entry.Checklist
+	}
+	if entry.Revision == 0 {
+		return errRevisionMissing
+	}
+	err := app.restClient.doPut(fmt.Sprintf("/todos/%s", entry.Id), updateParams, nil, ifMatch(entry.Revision))
+	if err != nil {
+		log.Errorf("Error putting a todo with the id '%s': %v\n", entry.Id, err)
+		return err
+	}
+	delete(app.revisions, entry.Id)
+	return nil
+}
+
+func (app appRemote) findArchived(resolvedFrom time.Time, resolvedTo time.Time) ([]todoModel, ShortIdMap) {
+	response := TodosResponse{}
+	query := url.Values{}
+	if !resolvedFrom.IsZero() {
+		query.Set("resolvedFrom", resolvedFrom.Format(time.RFC3339))
+	}
+	if !resolvedTo.IsZero() {
+		query.Set("resolvedTo", resolvedTo.Format(time.RFC3339))
+	}
+	path := "/archive"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	err := app.restClient.doGet(path, &response)
+	if err != nil {
+		log.Errorf("Error requesting archived todos: %v\n", err)
+	}
+	app.rememberRevisions(response.Todos)
+	return response.Todos, response.ShortIdMap
+}
+
+func (app appRemote) findInArchive(searchFor string) (*todoModel, string) {
+	response := TodosResponse{}
+	searchParams := SearchBody{SearchFor: searchFor}
+	err := app.restClient.doPost("/archive/search", searchParams, &response)
+	if err != nil {
+		log.Errorf("Error finding an archived todo for '%s': %v\n", searchFor, err)
+	}
+	app.rememberRevisions(response.Todos)
+	var responseTodo *todoModel
+	responseShortId := ""
+	if len(response.Todos) > 0 {
+		responseTodo = &response.Todos[0]
+		responseShortId = response.ShortIdMap[response.Todos[0].Id.String()]
+	}
+	return responseTodo, responseShortId
+}
+
+func (app appRemote) reopen(todoId uuid.UUID) error {
+	revision, ok := app.revisions[todoId]
+	if !ok {
+		archived, _ := app.findInArchive(todoId.String())
+		if archived == nil || archived.Id != todoId {
+			return fmt.Errorf("no archived todo by the id '%s' found", todoId)
+		}
+		revision = archived.Revision
+	}
+	err := app.restClient.doPost(fmt.Sprintf("/archive/%s/reopened", todoId), nil, nil, ifMatch(revision))
+	if err != nil {
+		log.Errorf("Error posting an archived todo as reopened with the id '%s': %v\n", todoId, err)
+		return err
+	}
+	delete(app.revisions, todoId)
+	return nil
+}
+
+func (app appRemote) addChecklistItem(todoId uuid.UUID, text string) error {
+	itemParams := ChecklistItemBody{Text: text}
+	revision, err := app.revisionOf(todoId)
+	if err != nil {
+		return err
+	}
+	err = app.restClient.doPost(fmt.Sprintf("/todos/%s/checklist", todoId), itemParams, nil, ifMatch(revision))
+	if err != nil {
+		log.Errorf("Error posting a checklist item for a todo with the id '%s': %v\n", todoId, err)
+		return err
+	}
+	delete(app.revisions, todoId)
+	return nil
+}
+
+func (app appRemote) checkChecklistItem(todoId uuid.UUID, index int, done bool) error {
+	itemParams := ChecklistItemBody{Done: done}
+	revision, err := app.revisionOf(todoId)
+	if err != nil {
+		return err
+	}
+	err = app.restClient.doPut(fmt.Sprintf("/todos/%s/checklist/%d", todoId, index), itemParams, nil, ifMatch(revision))
+	if err != nil {
+		log.Errorf("Error putting checklist item %d of a todo with the id '%s': %v\n", index, todoId, err)
+		return err
+	}
+	delete(app.revisions, todoId)
+	return nil
+}
+
+func (app appRemote) removeChecklistItem(todoId uuid.UUID, index int) error {
+	revision, err := app.revisionOf(todoId)
+	if err != nil {
+		return err
+	}
+	err = app.restClient.doDelete(fmt.Sprintf("/todos/%s/checklist/%d", todoId, index), ifMatch(revision))
+	if err != nil {
+		log.Errorf("Error deleting checklist item %d of a todo with the id '%s': %v\n", index, todoId, err)
+		return err
+	}
+	delete(app.revisions, todoId)
+	return nil
+}
+
+func (app appRemote) block(todoId uuid.UUID, blockerId uuid.UUID) error {
+	blockerParams := BlockerBody{BlockerId: blockerId}
+	revision, err := app.revisionOf(todoId)
+	if err != nil {
+		return err
+	}
+	err = app.restClient.doPost(fmt.Sprintf("/todos/%s/blockers", todoId), blockerParams, nil, ifMatch(revision))
+	if err != nil {
+		log.Errorf("Error posting a blocker for a todo with the id '%s': %v\n", todoId, err)
+		return err
+	}
+	delete(app.revisions, todoId)
+	return nil
+}
+
+func (app appRemote) unblock(todoId uuid.UUID, blockerId uuid.UUID) error {
+	revision, err := app.revisionOf(todoId)
+	if err != nil {
+		return err
+	}
+	err = app.restClient.doDelete(fmt.Sprintf("/todos/%s/blockers/%s", todoId, blockerId), ifMatch(revision))
+	if err != nil {
+		log.Errorf("Error deleting the blocker '%s' of a todo with the id '%s': %v\n", blockerId, todoId, err)
+		return err
+	}
+	delete(app.revisions, todoId)
+	return nil
+}
+
+// watch streams the events from the server, reconnecting after the last event seen whenever the stream breaks
+func (app appRemote) watch(lastEventId string, handle func(event changeEvent)) error {
+	connected := false
+	for {
+		err := app.restClient.doStream("/events", lastEventId, func(id string, eventType string, data string) {
+			connected = true
+			event := changeEvent{}
+			err := json.Unmarshal([]byte(data), &event)
+			if err != nil {
+				log.Errorf("Skipping the event '%s' not understood: %v\n", id, err)
+				return
+			}
+			lastEventId = event.Id
+			handle(event)
+		})
+		if err != nil && !connected {
+			log.Errorf("Error streaming the events: %v\n", err)
+			return err
+		}
+		connected = true
+		log.Debugf("Event stream ended, reconnecting: %v\n", err)
+		time.Sleep(watchReconnectDelay)
+	}
+}
+
+const watchReconnectDelay = 2 * time.Second
+
+func (app appRemote) rememberRevisions(todos []todoModel) {
+	for _, todo := range todos {
+		app.revisions[todo.Id] = todo.Revision
+	}
+}
+
+// revisionOf returns the revision of the todo as last seen, requesting it for a todo not seen yet
+func (app appRemote) revisionOf(todoId uuid.UUID) (int, error) {
+	revision, ok := app.revisions[todoId]
+	if ok {
+		return revision, nil
+	}
+	todo := todoModel{}
+	err := app.restClient.doGet(fmt.Sprintf("/todos/%s", todoId), &todo)
+	if err != nil {
+		log.Errorf("Error requesting a todo with the id '%s': %v\n", todoId, err)
+		return 0, err
+	}
+	return todo.Revision, nil
+}
+
+func (app appRemote) backup() (backupBundle, error) {
+	bundle := backupBundle{}
+	err := app.restClient.doGet("/admin/backup", &bundle)
+	if err != nil {
+		log.Errorf("Error requesting a backup: %v\n", err)
+		return bundle, err
+	}
+	return bundle, nil
+}
+
+func (app appRemote) restore(bundle backupBundle, mode restoreMode) (restoreResult, error) {
+	result := restoreResult{}
+	err := app.restClient.doPost("/admin/restore?mode="+url.QueryEscape(string(mode)), bundle, &result)
+	if err != nil {
+		log.Errorf("Error posting a backup to restore: %v\n", err)
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// loadRestTokens collects the bearer tokens of rest_tokens and the lines of rest_token_file
+func loadRestTokens(config config) ([]string, error) {
+	tokens := make([]string, 0)
+	for _, token := range strings.Split(config.RestTokens, ",") {
+		token = strings.TrimSpace(token)
+		if len(token) > 0 {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(config.RestTokenFile) > 0 {
+		data, err := os.ReadFile(config.RestTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read token file: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if len(line) > 0 && !strings.HasPrefix(line, "#") {
+				tokens = append(tokens, line)
+			}
+		}
+		if len(tokens) == 0 {
+			return nil, errors.New("token file " + config.RestTokenFile + " contains no token")
+		}
+	}
+	return tokens, nil
+}
+
+// tokenAuthMiddleware rejects every request without one of the tokens as bearer token
+func tokenAuthMiddleware(tokens []string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isAuthorized(r, tokens) {
+				log.Debugf("Rejecting unauthorized request: %s %s\n", r.Method, r.URL.Path)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="todo"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte("Missing or invalid bearer token"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isAuthorized(r *http.Request, tokens []string) bool {
+	var given []byte
+	header := r.Header.Get("Authorization")
+	if len(header) > len("Bearer ") && strings.EqualFold("Bearer ", header[:len("Bearer ")]) {
+		given = []byte(strings.TrimSpace(header[len("Bearer "):]))
+	} else if strings.EqualFold(r.Method, "GET") && strings.HasSuffix(r.URL.Path, ".ics") {
+		// calendar apps subscribing to a feed cannot send a header, so the token may be part of the url
+		given = []byte(r.URL.Query().Get("token"))
+	}
+	if len(given) == 0 {
+		return false
+	}
+	authorized := false
+	for _, token := range tokens {
+		// compare with every token in constant time, not to leak which one matched how far
+		if subtle.ConstantTimeCompare(given, []byte(token)) == 1 {
+			authorized = true
+		}
+	}
+	return authorized
+}
+
+// checkRestExposure refuses to serve requests without a token on any other interface than the loopback one
+func checkRestExposure(host string, tokens []string) error {
+	if len(tokens) == 0 && !isLoopbackHost(host) {
+		return fmt.Errorf("listening on %s needs a token, configure rest_tokens or rest_token_file, or set rest_base_host to 127.0.0.1", host)
+	}
+	return nil
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold("localhost", host) {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newRemoteTransport trusts the certificate authority of remote_ca_file, or any certificate when
+// remote_insecure_skip_verify is set
+func newRemoteTransport(config config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(config.RemoteCaFile) == 0 && !config.RemoteInsecure {
+		return transport, nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.RemoteInsecure}
+	if len(config.RemoteCaFile) > 0 {
+		data, err := os.ReadFile(config.RemoteCaFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.New("ca file " + config.RemoteCaFile + " contains no PEM certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
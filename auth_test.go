@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/pem"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadRestTokens(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "tokens")
+	assertTrue(t, os.WriteFile(tokenFile, []byte("# laptop\nthird\n\n"), 0600) == nil)
+	tokens, err := loadRestTokens(config{RestTokens: "first, second", RestTokenFile: tokenFile})
+	assertTrue(t, err == nil)
+	assertEquals(t, "first second third", strings.Join(tokens, " "))
+
+	_, err = loadRestTokens(config{RestTokenFile: filepath.Join(t.TempDir(), "missing")})
+	assertTrue(t, err != nil)
+}
+
+func TestTokenAuthMiddleware(t *testing.T) {
+	handler := tokenAuthMiddleware([]string{"secret"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	for header, expected := range map[string]int{"": 401, "Bearer wrong": 401, "Basic secret": 401, "Bearer secret": 204, "bearer secret": 204} {
+		req := httptest.NewRequest("GET", "/todos", nil)
+		if len(header) > 0 {
+			req.Header.Set("Authorization", header)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		assertTrue(t, recorder.Code == expected)
+	}
+}
+
+func TestCalendarHandler_keepsTheTokenOutOfTheLog(t *testing.T) {
+	var logged bytes.Buffer
+	level := log.GetLevel()
+	log.SetOutput(&logged)
+	log.SetLevel(log.DebugLevel)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetLevel(level)
+	})
+	_, server := newTestRestServer(t)
+	handler := tokenAuthMiddleware([]string{"secret"})(server.Config.Handler)
+	for _, token := range []string{"secret", "wrong"} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/calendar.ics?token="+token, nil))
+	}
+	assertTrue(t, strings.Contains(logged.String(), "/calendar.ics"))
+	assertFalse(t, strings.Contains(logged.String(), "secret"))
+	assertFalse(t, strings.Contains(logged.String(), "wrong"))
+}
+
+func TestRestClient_sendsTokenOverTls(t *testing.T) {
+	server := httptest.NewTLSServer(tokenAuthMiddleware([]string{"secret"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"todos":[]}`))
+	})))
+	defer server.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assertTrue(t, os.WriteFile(caFile, certificate, 0600) == nil)
+
+	client, err := newRestClient(config{RemoteBaseUrl: server.URL, RemoteToken: "secret", RemoteCaFile: caFile})
+	assertTrue(t, err == nil)
+	assertTrue(t, client.doGet("/todos", &TodosResponse{}) == nil)
+
+	client, _ = newRestClient(config{RemoteBaseUrl: server.URL, RemoteToken: "secret", RemoteInsecure: true})
+	assertTrue(t, client.doGet("/todos", &TodosResponse{}) == nil)
+
+	client, _ = newRestClient(config{RemoteBaseUrl: server.URL, RemoteToken: "secret"})
+	assertTrue(t, client.doGet("/todos", &TodosResponse{}) != nil)
+
+	client, _ = newRestClient(config{RemoteBaseUrl: server.URL, RemoteCaFile: caFile})
+	err = client.doGet("/todos", &TodosResponse{})
+	assertTrue(t, err != nil && strings.Contains(err.Error(), "401"))
+}
+
+func TestIsAuthorized_tokenQueryOnlyForCalendar(t *testing.T) {
+	tokens := []string{"secret"}
+	assertTrue(t, isAuthorized(httptest.NewRequest("GET", "/calendar.ics?token=secret", nil), tokens))
+	assertFalse(t, isAuthorized(httptest.NewRequest("GET", "/calendar.ics?token=wrong", nil), tokens))
+	assertFalse(t, isAuthorized(httptest.NewRequest("GET", "/todos?token=secret", nil), tokens))
+}
+
+func TestCheckRestExposure(t *testing.T) {
+	assertTrue(t, checkRestExposure("127.0.0.1", nil) == nil)
+	assertTrue(t, checkRestExposure("localhost", nil) == nil)
+	assertTrue(t, checkRestExposure("0.0.0.0", nil) != nil)
+	assertTrue(t, checkRestExposure("192.168.1.20", nil) != nil)
+	assertTrue(t, checkRestExposure("0.0.0.0", []string{"secret"}) == nil)
+}
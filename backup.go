@@ -0,0 +1,270 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// backupVersion is the version of the bundle format written, bundles of a higher version are refused
+const backupVersion = 1
+
+const backupManifestName = "manifest.json"
+
+// maxBackupSize is the most bytes of a backup read, compressed or not
+const maxBackupSize = 64 << 20
+
+// maxBackupEntrySize is the most bytes of a single file read from a tar.gz backup, guarding against archives
+// decompressing to far more than they take
+const maxBackupEntrySize = 4 << 20
+
+type backupFormat string
+
+const (
+	BackupFormatJson  backupFormat = "json"
+	BackupFormatTarGz backupFormat = "tar.gz"
+)
+
+func parseBackupFormat(value string) (backupFormat, error) {
+	switch strings.ToLower(value) {
+	case "", "json":
+		return BackupFormatJson, nil
+	case "tar.gz", "tgz":
+		return BackupFormatTarGz, nil
+	}
+	return BackupFormatJson, errors.New(fmt.Sprintf("backup format %s unknown, expecting json or tar.gz.", value))
+}
+
+// backupFormatOfFile tells the format by the file name, json unless it ends with .tar.gz or .tgz
+func backupFormatOfFile(file string) backupFormat {
+	if strings.HasSuffix(file, ".tar.gz") || strings.HasSuffix(file, ".tgz") {
+		return BackupFormatTarGz
+	}
+	return BackupFormatJson
+}
+
+type restoreMode string
+
+const (
+	// RestoreModeMerge adds the todos of the bundle, leaving those with an id already present as they are
+	RestoreModeMerge restoreMode = "merge"
+	// RestoreModeReplace deletes all todos, active and archived, before adding those of the bundle
+	RestoreModeReplace restoreMode = "replace"
+)
+
+func parseRestoreMode(value string) (restoreMode, error) {
+	switch strings.ToLower(value) {
+	case "", "merge":
+		return RestoreModeMerge, nil
+	case "replace":
+		return RestoreModeReplace, nil
+	}
+	return RestoreModeMerge, errors.New(fmt.Sprintf("restore mode %s unknown, expecting merge or replace.", value))
+}
+
+// backupBundle holds every active and archived todo, ids and notification state included
+type backupBundle struct {
+	Version   int         `json:"version"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Todos     []todoModel `json:"todos"`
+	Archive   []todoModel `json:"archive"`
+}
+
+type backupManifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type restoreResult struct {
+	Restored  int               `json:"restored"`
+	Archived  int               `json:"archived"`
+	Removed   int               `json:"removed"`
+	Conflicts []restoreConflict `json:"conflicts"`
+}
+
+type restoreConflict struct {
+	Id     string `json:"id"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
+
+func writeBackup(out io.Writer, bundle backupBundle, format backupFormat) error {
+	if format == BackupFormatTarGz {
+		return writeBackupTarGz(out, bundle)
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bundle)
+}
+
+// writeBackupTarGz lays out the bundle like the todo directory, a manifest next to one json file per todo in todos/
+// and archive/
+func writeBackupTarGz(out io.Writer, bundle backupBundle) error {
+	gzipWriter := gzip.NewWriter(out)
+	tarWriter := tar.NewWriter(gzipWriter)
+	err := writeTarJson(tarWriter, backupManifestName, backupManifest{Version: bundle.Version, CreatedAt: bundle.CreatedAt}, bundle.CreatedAt)
+	for _, entry := range bundle.Todos {
+		if err == nil {
+			err = writeTarJson(tarWriter, "todos/"+entry.Id.String()+".json", entry, bundle.CreatedAt)
+		}
+	}
+	for _, entry := range bundle.Archive {
+		if err == nil {
+			err = writeTarJson(tarWriter, "archive/"+entry.Id.String()+".json", entry, bundle.CreatedAt)
+		}
+	}
+	if err == nil {
+		err = tarWriter.Close()
+	}
+	if err == nil {
+		err = gzipWriter.Close()
+	}
+	return err
+}
+
+func writeTarJson(tarWriter *tar.Writer, name string, value interface{}, modTime time.Time) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg})
+	if err != nil {
+		return err
+	}
+	_, err = tarWriter.Write(data)
+	return err
+}
+
+// readBackup reads a bundle written in either format, telling a tar.gz one by the gzip magic bytes
+func readBackup(data []byte) (backupBundle, error) {
+	bundle := backupBundle{}
+	var err error
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		bundle, err = readBackupTarGz(data)
+	} else {
+		err = json.Unmarshal(data, &bundle)
+	}
+	if err != nil {
+		return bundle, fmt.Errorf("could not read backup: %w", err)
+	}
+	if bundle.Version < 1 || bundle.Version > backupVersion {
+		return bundle, errors.New(fmt.Sprintf("backup version %d not supported, expecting up to %d", bundle.Version, backupVersion))
+	}
+	return bundle, nil
+}
+
+func readBackupTarGz(data []byte) (backupBundle, error) {
+	bundle := backupBundle{}
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return bundle, err
+	}
+	tarReader := tar.NewReader(gzipReader)
+	total := int64(0)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return bundle, nil
+		}
+		if err != nil {
+			return bundle, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(io.LimitReader(tarReader, maxBackupEntrySize+1))
+		if err != nil {
+			return bundle, err
+		}
+		total += int64(len(content))
+		if len(content) > maxBackupEntrySize {
+			return bundle, fmt.Errorf("%s: exceeds %d bytes", header.Name, maxBackupEntrySize)
+		}
+		if total > maxBackupSize {
+			return bundle, fmt.Errorf("backup exceeds %d bytes when decompressed", maxBackupSize)
+		}
+		dir, name := path.Split(header.Name)
+		if name == backupManifestName && len(dir) == 0 {
+			manifest := backupManifest{}
+			err = json.Unmarshal(content, &manifest)
+			bundle.Version, bundle.CreatedAt = manifest.Version, manifest.CreatedAt
+		} else if dir == "todos/" || dir == "archive/" {
+			entry := todoModel{}
+			err = json.Unmarshal(content, &entry)
+			if dir == "todos/" {
+				bundle.Todos = append(bundle.Todos, entry)
+			} else {
+				bundle.Archive = append(bundle.Archive, entry)
+			}
+		}
+		if err != nil {
+			return bundle, fmt.Errorf("%s: %w", header.Name, err)
+		}
+	}
+}
+
+func (app *appLocal) backup() (backupBundle, error) {
+	return backupBundle{Version: backupVersion, CreatedAt: time.Now(), Todos: mapTodos(app.repo.readAllEntries()), Archive: mapTodos(app.repo.readArchivedEntries())}, nil
+}
+
+// restore writes the todos of the bundle as they are, keeping their ids, revisions and notification state. Merging
+// reports a todo whose id is present already as conflict, like one whose title is taken by another active todo.
+// The archive goes first, as an archived occurrence of a recurring todo shares the title of the active one.
+func (app *appLocal) restore(bundle backupBundle, mode restoreMode) (restoreResult, error) {
+	result := restoreResult{Conflicts: make([]restoreConflict, 0)}
+	entries := make([]todo, 0, len(bundle.Archive)+len(bundle.Todos))
+	for _, model := range append(append([]todoModel{}, bundle.Archive...), bundle.Todos...) {
+		entry, err := mapTodoModel(model)
+		if err != nil {
+			return result, fmt.Errorf("todo %s %s: %w", model.Id, model.Title, err)
+		}
+		entries = append(entries, entry)
+	}
+	active := app.repo.readAllEntries()
+	archived := app.repo.readArchivedEntries()
+	present := make(map[string]bool)
+	if mode == RestoreModeReplace {
+		for _, entry := range append(active, archived...) {
+			app.repo.deleteEntry(entry)
+			result.Removed++
+		}
+		for _, entry := range active {
+			app.events.publish(ChangeTypeDeleted, entry)
+		}
+	} else {
+		for _, entry := range append(active, archived...) {
+			present[entry.Id.String()] = true
+		}
+	}
+	for i, entry := range entries {
+		if present[entry.Id.String()] {
+			result.Conflicts = append(result.Conflicts, restoreConflict{Id: entry.Id.String(), Title: entry.Title, Reason: "a todo with this id is present already"})
+			continue
+		}
+		err := app.repo.insertEntry(entry)
+		if err != nil {
+			result.Conflicts = append(result.Conflicts, restoreConflict{Id: entry.Id.String(), Title: entry.Title, Reason: err.Error()})
+			continue
+		}
+		present[entry.Id.String()] = true
+		if i >= len(bundle.Archive) {
+			result.Restored++
+			app.events.publish(ChangeTypeCreated, entry)
+			continue
+		}
+		inserted, err := app.repo.readEntryById(entry.Id)
+		if err != nil {
+			return result, err
+		}
+		app.repo.archiveEntry(inserted)
+		result.Archived++
+	}
+	return result, nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMapTodoModel_keepsNotificationState(t *testing.T) {
+	notifiedAt := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	entry := todo{Title: "title", Id: uuid.New(), Due: notifiedAt, Revision: 4, Priority: PriorityHigh,
+		Notification: notification{Type: NotificationTypeRepeat, Interval: 30 * time.Minute, EscalateAfter: 3, History: []time.Time{notifiedAt},
+			Reminders: []reminder{{Before: 24 * time.Hour, SentAt: notifiedAt.Add(-24 * time.Hour)}}}}
+	restored, err := mapTodoModel(mapTodo(entry))
+	assertTrue(t, err == nil)
+	assertTrue(t, restored.Id == entry.Id)
+	assertTrue(t, restored.Revision == 4)
+	assertTrue(t, restored.Notification.Interval == 30*time.Minute)
+	assertTrue(t, restored.Notification.EscalateAfter == 3)
+	assertTrue(t, len(restored.Notification.History) == 1 && restored.Notification.History[0].Equal(notifiedAt))
+	assertTrue(t, restored.Notification.NotifiedAt.IsZero())
+	assertTrue(t, restored.Notification.Reminders[0].SentAt.Equal(notifiedAt.Add(-24*time.Hour)))
+}
+
+func TestBackup_roundTripInBothFormats(t *testing.T) {
+	bundle := backupBundle{Version: backupVersion, CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Todos:   []todoModel{{Title: "active", Id: uuid.New()}},
+		Archive: []todoModel{{Title: "resolved", Id: uuid.New()}}}
+	for _, format := range []backupFormat{BackupFormatJson, BackupFormatTarGz} {
+		out := &bytes.Buffer{}
+		assertTrue(t, writeBackup(out, bundle, format) == nil)
+		read, err := readBackup(out.Bytes())
+		assertTrue(t, err == nil)
+		assertTrue(t, read.Version == backupVersion)
+		assertTrue(t, read.CreatedAt.Equal(bundle.CreatedAt))
+		assertTrue(t, len(read.Todos) == 1 && read.Todos[0].Id == bundle.Todos[0].Id)
+		assertTrue(t, len(read.Archive) == 1 && read.Archive[0].Id == bundle.Archive[0].Id)
+	}
+
+	_, err := readBackup([]byte(`{"version": 99}`))
+	assertTrue(t, err != nil)
+	_, err = readBackup([]byte(`{"todos": []}`))
+	assertTrue(t, err != nil)
+}
+
+func TestRestore_mergeAndReplace(t *testing.T) {
+	source := newTestApp(t)
+	assertTrue(t, source.add(todoModel{Title: "daily", Due: time.Now(), Recurrence: &recurrenceModel{Frequency: "daily"}}) == nil)
+	daily, _ := source.find("daily")
+	assertTrue(t, source.resolve(daily.Id) == nil)
+	assertTrue(t, source.add(todoModel{Title: "other", Due: time.Now()}) == nil)
+	bundle, err := source.backup()
+	assertTrue(t, err == nil)
+	assertTrue(t, len(bundle.Todos) == 2)
+	assertTrue(t, len(bundle.Archive) == 1)
+
+	target := newTestApp(t)
+	assertTrue(t, target.add(todoModel{Title: "local", Due: time.Now()}) == nil)
+	result, err := target.restore(bundle, RestoreModeMerge)
+	assertTrue(t, err == nil)
+	assertTrue(t, result.Restored == 2)
+	assertTrue(t, result.Archived == 1)
+	assertTrue(t, len(result.Conflicts) == 0)
+	archived, _ := target.findInArchive("daily")
+	assertTrue(t, archived != nil && archived.Id == daily.Id)
+
+	result, err = target.restore(bundle, RestoreModeMerge)
+	assertTrue(t, err == nil)
+	assertTrue(t, result.Restored == 0)
+	assertTrue(t, len(result.Conflicts) == 3)
+
+	result, err = target.restore(bundle, RestoreModeReplace)
+	assertTrue(t, err == nil)
+	assertTrue(t, result.Removed == 4)
+	assertTrue(t, result.Restored == 2)
+	assertTrue(t, len(result.Conflicts) == 0)
+	local, _ := target.find("local")
+	assertTrue(t, local == nil)
+}
+
+func TestRestoreHandler(t *testing.T) {
+	app, server := newTestRestServer(t)
+	bundle := backupBundle{Version: backupVersion, Todos: []todoModel{{Title: "restored", Id: uuid.New(), Due: time.Now(), Notification: notificationModel{Type: "once"}}}}
+	data, _ := json.Marshal(bundle)
+	res, err := http.Post(server.URL+"/admin/restore?mode=merge", "application/json", bytes.NewReader(data))
+	assertTrue(t, err == nil)
+	result := restoreResult{}
+	assertTrue(t, json.NewDecoder(res.Body).Decode(&result) == nil)
+	res.Body.Close()
+	assertTrue(t, res.StatusCode == http.StatusOK)
+	assertTrue(t, result.Restored == 1)
+	entry, _ := app.find("restored")
+	assertTrue(t, entry != nil && entry.Id == bundle.Todos[0].Id)
+
+	res, err = http.Get(server.URL + "/admin/backup?format=tar.gz")
+	assertTrue(t, err == nil)
+	backup := &bytes.Buffer{}
+	_, _ = backup.ReadFrom(res.Body)
+	res.Body.Close()
+	assertEquals(t, "application/gzip", res.Header.Get("Content-Type"))
+	read, err := readBackup(backup.Bytes())
+	assertTrue(t, err == nil)
+	assertTrue(t, len(read.Todos) == 1)
+}
+
+func TestReadBackupTarGz_refusesOversizedEntry(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	content := bytes.Repeat([]byte(" "), maxBackupEntrySize+1)
+	assertTrue(t, tarWriter.WriteHeader(&tar.Header{Name: "todos/huge.json", Mode: 0600, Size: int64(len(content))}) == nil)
+	_, err := tarWriter.Write(content)
+	assertTrue(t, err == nil)
+	assertTrue(t, tarWriter.Close() == nil)
+	assertTrue(t, gzipWriter.Close() == nil)
+
+	_, err = readBackup(buffer.Bytes())
+	assertTrue(t, err != nil)
+	assertTrue(t, strings.Contains(err.Error(), "todos/huge.json"))
+}
+
+func TestRestServer_refusesAdminRoutesWithoutTokens(t *testing.T) {
+	app := newTestApp(t)
+	rs := newRestServer(app, nil, reportOptions{})
+	for _, tokens := range [][]string{nil, {"secret"}} {
+		r := mux.NewRouter()
+		rs.register(r, tokens)
+		for _, path := range []string{"/admin/backup", "/admin/restore"} {
+			req := httptest.NewRequest("GET", path, nil)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+			if len(tokens) == 0 {
+				assertTrue(t, recorder.Code == http.StatusForbidden)
+			} else {
+				assertFalse(t, recorder.Code == http.StatusForbidden)
+			}
+		}
+	}
+}
+
+func TestRestoreHandler_refusesOversizedBody(t *testing.T) {
+	_, server := newTestRestServer(t)
+	res, err := http.Post(server.URL+"/admin/restore", "application/json", io.LimitReader(neverEndingReader{}, maxBackupSize+1))
+	assertTrue(t, err == nil)
+	res.Body.Close()
+	assertTrue(t, res.StatusCode == http.StatusRequestEntityTooLarge)
+}
+
+type neverEndingReader struct{}
+
+func (neverEndingReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = ' '
+	}
+	return len(p), nil
+}
+
+func TestCliBackup_reportsMissingFormat(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cli := cli{app: newTestApp(t), location: locationBerlin(), output: output{stdout: stdout, stderr: stderr}}
+
+	cli.backup([]string{"--format"})
+
+	assertEquals(t, "Could not back up: format missing\n", stderr.String())
+	assertEquals(t, "", stdout.String())
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// clockRange is a range of the day like 22:00-07:00, wrapping past midnight when it ends before it starts
+type clockRange struct {
+	from time.Duration
+	to   time.Duration
+}
+
+func parseClockRange(value string) (clockRange, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 2 {
+		return clockRange{}, fmt.Errorf("time range %s not understood, expecting something like 22:00-07:00", value)
+	}
+	from, err := parseClockOffset(parts[0])
+	if err != nil {
+		return clockRange{}, err
+	}
+	to, err := parseClockOffset(parts[1])
+	if err != nil {
+		return clockRange{}, err
+	}
+	return clockRange{from: from, to: to}, nil
+}
+
+func parseClockOffset(value string) (time.Duration, error) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("time of day %s not understood", value)
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+func (r clockRange) contains(t time.Time) bool {
+	offset := clockOffsetOf(t)
+	if r.from <= r.to {
+		return r.from <= offset && offset < r.to
+	}
+	return offset >= r.from || offset < r.to
+}
+
+func clockOffsetOf(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+func atClockOffset(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+// calendar knows the quiet hours, in which the server holds back notifications, and the working time
+// due dates can be pushed onto
+type calendar struct {
+	quietHours     *clockRange
+	quietOnDaysOff bool
+	workingDays    map[time.Weekday]bool
+	workingHours   clockRange
+}
+
+func newCalendar(config config) (*calendar, error) {
+	cal := &calendar{quietOnDaysOff: config.QuietOnDaysOff, workingDays: make(map[time.Weekday]bool)}
+	if len(config.QuietHours) > 0 {
+		quietHours, err := parseClockRange(config.QuietHours)
+		if err != nil {
+			return nil, err
+		}
+		cal.quietHours = &quietHours
+	}
+	for _, name := range strings.Split(config.WorkingDays, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+		weekday, ok := weekdayNames[name]
+		if !ok {
+			return nil, fmt.Errorf("working day %s unknown", name)
+		}
+		cal.workingDays[weekday] = true
+	}
+	if len(cal.workingDays) == 0 {
+		return nil, fmt.Errorf("at least one working day is needed")
+	}
+	workingHours, err := parseClockRange(config.WorkingHours)
+	if err != nil {
+		return nil, err
+	}
+	if workingHours.to <= workingHours.from {
+		return nil, fmt.Errorf("working hours %s must end after they start on the same day", config.WorkingHours)
+	}
+	cal.workingHours = workingHours
+	return cal, nil
+}
+
+// isQuiet reports whether notifications are held back at t
+func (c *calendar) isQuiet(t time.Time) bool {
+	if c.quietOnDaysOff && !c.workingDays[t.Weekday()] {
+		return true
+	}
+	return c.quietHours != nil && c.quietHours.contains(t)
+}
+
+// nextWorkingTime returns t if it lies within the working time, else the start of the next working time
+func (c *calendar) nextWorkingTime(t time.Time) time.Time {
+	for i := 0; i < 8; i++ {
+		day := dateOf(t).AddDate(0, 0, i)
+		if !c.workingDays[day.Weekday()] {
+			continue
+		}
+		start := atClockOffset(day, c.workingHours.from)
+		if i > 0 || t.Before(start) {
+			return start
+		}
+		if t.Before(atClockOffset(day, c.workingHours.to)) {
+			return t
+		}
+	}
+	return t
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockRange_wrapsPastMidnight(t *testing.T) {
+	quiet, err := parseClockRange("22:00-07:00")
+	assertTrue(t, err == nil)
+	assertTrue(t, quiet.contains(parseRFC3339("2023-11-18T23:30:00+01:00")))
+	assertTrue(t, quiet.contains(parseRFC3339("2023-11-18T03:00:00+01:00")))
+	assertFalse(t, quiet.contains(parseRFC3339("2023-11-18T07:00:00+01:00")))
+	assertFalse(t, quiet.contains(parseRFC3339("2023-11-18T12:00:00+01:00")))
+	_, err = parseClockRange("late")
+	assertTrue(t, err != nil)
+}
+
+func TestNewCalendar_invalidConfig(t *testing.T) {
+	_, err := newCalendar(config{WorkingDays: "mon,funday", WorkingHours: "09:00-17:00"})
+	assertTrue(t, err != nil)
+	_, err = newCalendar(config{WorkingDays: "mon", WorkingHours: "17:00-09:00"})
+	assertTrue(t, err != nil)
+	_, err = newCalendar(config{WorkingDays: "mon", WorkingHours: "09:00-17:00", QuietHours: "22-7"})
+	assertTrue(t, err != nil)
+}
+
+func TestCalendarIsQuiet_onDaysOff(t *testing.T) {
+	cal, err := newCalendar(config{WorkingDays: "mon,tue,wed,thu,fri", WorkingHours: "09:00-17:00", QuietOnDaysOff: true})
+	assertTrue(t, err == nil)
+	assertTrue(t, cal.isQuiet(parseRFC3339("2023-11-18T12:00:00+01:00")))
+	assertFalse(t, cal.isQuiet(parseRFC3339("2023-11-20T03:00:00+01:00")))
+}
+
+func TestCalendarNextWorkingTime(t *testing.T) {
+	cal, err := newCalendar(config{WorkingDays: "mon,tue,wed,thu,fri", WorkingHours: "09:00-17:00"})
+	assertTrue(t, err == nil)
+	// Friday evening moves on to Monday morning
+	assertEquals(t, "2023-11-20T09:00:00+01:00", cal.nextWorkingTime(parseRFC3339("2023-11-17T18:30:00+01:00")).Format(time.RFC3339))
+	// Saturday moves on to Monday morning
+	assertEquals(t, "2023-11-20T09:00:00+01:00", cal.nextWorkingTime(parseRFC3339("2023-11-18T11:00:00+01:00")).Format(time.RFC3339))
+	// early on a working day moves on to the start of that day
+	assertEquals(t, "2023-11-21T09:00:00+01:00", cal.nextWorkingTime(parseRFC3339("2023-11-21T06:15:00+01:00")).Format(time.RFC3339))
+	// within the working time it stays
+	assertEquals(t, "2023-11-21T14:20:00+01:00", cal.nextWorkingTime(parseRFC3339("2023-11-21T14:20:00+01:00")).Format(time.RFC3339))
+}
+
+func TestTimerResolveWithin_onlyMovesRelativeDueDates(t *testing.T) {
+	cal, _ := newCalendar(config{WorkingDays: "mon,tue,wed,thu,fri", WorkingHours: "09:00-17:00"})
+	friday := parseRFC3339("2023-11-17T16:00:00+01:00").In(locationBerlin())
+	_, timer := ParseTimer([]string{"title", "in", "2", "hours"}, locationBerlin())
+	assertEquals(t, "2023-11-20T09:00:00+01:00", timer.ResolveWithin(friday, cal).Format(time.RFC3339))
+	_, timer = ParseTimer([]string{"title", "tomorrow"}, locationBerlin())
+	assertEquals(t, "2023-11-20T09:00:00+01:00", timer.ResolveWithin(friday, cal).Format(time.RFC3339))
+	_, timer = ParseTimer([]string{"title", "2023-11-18", "10:00"}, locationBerlin())
+	assertEquals(t, "2023-11-18T10:00:00+01:00", timer.ResolveWithin(friday, cal).Format(time.RFC3339))
+}
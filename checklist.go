@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type checklistItem struct {
+	Text string `yaml:"text"`
+	Done bool   `yaml:"done"`
+}
+
+var checklistLineRegex = regexp.MustCompile(`(?m)^[ \t]*[-*] \[( |x|X)\][ \t]+(.*?)[ \t]*$\n?`)
+
+// checklistProgress counts the done and all items of a checklist
+func checklistProgress(items []checklistItemModel) (int, int) {
+	done := 0
+	for _, item := range items {
+		if item.Done {
+			done++
+		}
+	}
+	return done, len(items)
+}
+
+func isChecklistComplete(items []checklistItem) bool {
+	for _, item := range items {
+		if !item.Done {
+			return false
+		}
+	}
+	return len(items) > 0
+}
+
+// checklistIndex turns the 1-based index of an item into the position in the checklist
+func checklistIndex(items []checklistItem, index int) (int, error) {
+	if index < 1 || index > len(items) {
+		return 0, errors.New(fmt.Sprintf("checklist item %d not present, the checklist has %d items", index, len(items)))
+	}
+	return index - 1, nil
+}
+
+// formatChecklist renders the checklist like '- [x] text', one item per line
+func formatChecklist(items []checklistItemModel) string {
+	builder := strings.Builder{}
+	for _, item := range items {
+		mark := " "
+		if item.Done {
+			mark = "x"
+		}
+		builder.WriteString(fmt.Sprintf("- [%s] %s\n", mark, item.Text))
+	}
+	return builder.String()
+}
+
+// parseChecklist strips lines like '- [ ] text' or '- [x] text' from the input, returning them as checklist
+func parseChecklist(input string) (string, []checklistItemModel) {
+	items := make([]checklistItemModel, 0)
+	for _, match := range checklistLineRegex.FindAllStringSubmatch(input, -1) {
+		if len(match[2]) > 0 {
+			items = append(items, checklistItemModel{Text: match[2], Done: match[1] != " "})
+		}
+	}
+	return checklistLineRegex.ReplaceAllString(input, ""), items
+}
+
+func mapChecklist(items []checklistItem) []checklistItemModel {
+	if items == nil {
+		return nil
+	}
+	res := make([]checklistItemModel, 0, len(items))
+	for _, item := range items {
+		res = append(res, checklistItemModel{Text: item.Text, Done: item.Done})
+	}
+	return res
+}
+
+func mapChecklistModel(items []checklistItemModel) []checklistItem {
+	if items == nil {
+		return nil
+	}
+	res := make([]checklistItem, 0, len(items))
+	for _, item := range items {
+		res = append(res, checklistItem{Text: item.Text, Done: item.Done})
+	}
+	return res
+}
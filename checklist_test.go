@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseChecklist(t *testing.T) {
+	details, items := parseChecklist("Some details\n- [ ] first\n- [x] second\n  * [X] third  \n- [ ]\nmore details")
+	assertEquals(t, "Some details\n- [ ]\nmore details", details)
+	assertTrue(t, len(items) == 3)
+	assertEquals(t, "first", items[0].Text)
+	assertFalse(t, items[0].Done)
+	assertTrue(t, items[1].Done && items[2].Done)
+	assertEquals(t, "third", items[2].Text)
+}
+
+func TestFormatChecklist_roundTrip(t *testing.T) {
+	items := []checklistItemModel{{Text: "first", Done: true}, {Text: "second"}}
+	formatted := formatChecklist(items)
+	assertEquals(t, "- [x] first\n- [ ] second\n", formatted)
+	_, parsed := parseChecklist(formatted)
+	assertEquals(t, formatted, formatChecklist(parsed))
+}
+
+func TestAppLocal_checklistAutoResolves(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "title", Due: time.Now(), AutoResolve: true}) == nil)
+	entry, _ := app.find("title")
+	assertTrue(t, app.addChecklistItem(entry.Id, "first") == nil)
+	assertTrue(t, app.addChecklistItem(entry.Id, "second") == nil)
+	assertTrue(t, app.addChecklistItem(entry.Id, " ") != nil)
+	assertTrue(t, app.checkChecklistItem(entry.Id, 3, true) != nil)
+
+	assertTrue(t, app.checkChecklistItem(entry.Id, 1, true) == nil)
+	entry, _ = app.find("title")
+	done, total := checklistProgress(entry.Checklist)
+	assertTrue(t, done == 1 && total == 2)
+
+	assertTrue(t, app.checkChecklistItem(entry.Id, 2, true) == nil)
+	entry, _ = app.find("title")
+	assertTrue(t, entry == nil)
+	archived, _ := app.findInArchive("title")
+	assertTrue(t, archived != nil && !archived.ResolvedAt.IsZero())
+}
+
+func TestAppLocal_removeChecklistItem(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "title", Due: time.Now(), Checklist: []checklistItemModel{{Text: "first"}, {Text: "second"}}}) == nil)
+	entry, _ := app.find("title")
+	assertTrue(t, app.removeChecklistItem(entry.Id, 1) == nil)
+	entry, _ = app.find("title")
+	assertTrue(t, len(entry.Checklist) == 1)
+	assertEquals(t, "second", entry.Checklist[0].Text)
+}
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"github.com/fatih/color"
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 	"io"
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -52,9 +56,9 @@ func (cli *cli) run(args []string) {
 	case "add":
 		cli.add(arguments)
 	case "list":
-		cli.list()
+		cli.list(arguments)
 	case "due":
-		cli.due()
+		cli.due(arguments)
 	case "show":
 		cli.show(arguments)
 	case "del":
@@ -63,6 +67,40 @@ func (cli *cli) run(args []string) {
 		cli.resolve(arguments)
 	case "snooze":
 		cli.snooze(arguments)
+	case "edit":
+		cli.edit(arguments)
+	case "archive":
+		cli.archive(arguments)
+	case "reopen":
+		cli.reopen(arguments)
+	case "prio":
+		cli.prio(arguments)
+	case "item":
+		cli.item(arguments)
+	case "check":
+		cli.check(arguments, true)
+	case "uncheck":
+		cli.check(arguments, false)
+	case "tui":
+		cli.tui(arguments)
+	case "watch":
+		cli.watch()
+	case "export":
+		cli.export(arguments)
+	case "import":
+		cli.importFile(arguments)
+	case "report":
+		cli.report(arguments)
+	case "backup":
+		cli.backup(arguments)
+	case "restore":
+		cli.restore(arguments)
+	case "block":
+		cli.block(arguments, true)
+	case "unblock":
+		cli.block(arguments, false)
+	case "migrate":
+		migrateFsToSqlite(cli.cfg, cli.output)
 	default:
 		cli.Errorf("command unknown: %s\n", *command)
 		usage()
@@ -70,43 +108,371 @@ func (cli *cli) run(args []string) {
 	}
 }
 
+type addOptions struct {
+	noEdit  bool
+	details string
+	file    string
+	// stdin reads the title and details from stdin, leaving all arguments to the due date
+	stdin bool
+}
+
 func (cli *cli) add(arguments []string) {
+	arguments, options, err := parseAddOptions(arguments)
+	if err != nil {
+		cli.Errorf("Skip creating: %s\n", err)
+		return
+	}
+	if len(options.file) > 0 {
+		cli.addFromFile(options.file)
+		return
+	}
+
+	// a title read from stdin leaves all of the arguments to the due date, like in 'echo "buy milk" | todo add - tomorrow'
+	entry, err := cli.parseAddArguments(arguments, options.stdin)
+	if err != nil {
+		cli.Errorf("Skip creating %s: %s\n", entry.Title, err)
+		return
+	}
+
+	if options.noEdit || len(options.details) > 0 || options.stdin || !isTerminal(os.Stdin) {
+		cli.addWithoutEditor(entry, options.details, options.stdin)
+		return
+	}
+
+	userInput := cli.createDescriptionInput(entry.Title, entry.Due, entry.Recurrence, entry.Tags, entry.Project)
+	editorUserInput, err := cli.openInEditor(userInput)
+	if err != nil {
+		log.Debugf("Error processing input in editor: %v", err)
+	}
+	cleansedUserInput := cli.cleanseInput(editorUserInput)
+
+	if len(cleansedUserInput) == 0 {
+		cli.Errorf("Skip creating %s due to an empty title.\n", entry.Title)
+	} else {
+		entry.Title, entry.Details = cli.parseDescriptionInput(cleansedUserInput)
+		takeChecklist(&entry)
+		err := cli.app.add(entry)
+		if err != nil {
+			cli.Errorf("Could not create %s. Maybe this entry already exists? %s\n", entry.Title, err)
+		}
+	}
+
+}
+
+func parseAddOptions(arguments []string) ([]string, addOptions, error) {
+	options := addOptions{}
+	remaining := make([]string, 0, len(arguments))
+	for i := 0; i < len(arguments); i++ {
+		switch arguments[i] {
+		case "--no-edit", "-n":
+			options.noEdit = true
+		case "-":
+			options.stdin = true
+		case "--message", "-m", "--file", "-f":
+			if i+1 >= len(arguments) {
+				return arguments, options, fmt.Errorf("option %s requires a value", arguments[i])
+			}
+			if arguments[i] == "--file" || arguments[i] == "-f" {
+				options.file = arguments[i+1]
+			} else {
+				options.details = arguments[i+1]
+			}
+			i++
+		default:
+			remaining = append(remaining, arguments[i])
+		}
+	}
+	return remaining, options, nil
+}
+
+// parseAddArguments turns the arguments of the add command into a todo, the due date defaulting to one day from now.
+// With the title given elsewhere, the arguments may consist of the due date alone.
+func (cli *cli) parseAddArguments(arguments []string, titleElsewhere bool) (todoModel, error) {
 	var due time.Time
+	arguments, tags, project := ParseTags(arguments)
+	arguments, priority := ParsePriority(arguments)
+	arguments, reminders := ParseReminders(arguments)
+	arguments, setting := ParseNag(arguments)
+	arguments, recurrence := ParseRecurrence(arguments, cli.location)
+	if titleElsewhere {
+		// ParseTimer keeps the first argument for the title, which may be missing here
+		arguments = append([]string{""}, arguments...)
+	}
 	title, timer := ParseTimer(arguments, cli.location)
+	title = strings.TrimSpace(title)
+	if timer.Err() != nil {
+		return todoModel{Title: title}, timer.Err()
+	}
 	if !timer.isEmpty() {
-		due = timer.Resolve(time.Now())
+		due = cli.resolveDue(timer)
 	} else {
 		due = time.Now().Add(24 * time.Hour)
 	}
+	return todoModel{Title: title, Due: due, Notification: notificationModel{Type: setting.Type, Interval: setting.Interval, EscalateAfter: setting.EscalateAfter, Reminders: reminderModelsOf(reminders)}, Recurrence: recurrence, Tags: tags, Project: project, Priority: priority}, nil
+}
 
-	userInput := cli.createDescriptionInput(title, due)
-	editorUserInput, err := cli.openInEditor(userInput)
+// resolveDue resolves the timer from now, pushed onto the next working time when configured
+func (cli *cli) resolveDue(timer *Timer) time.Time {
+	if cli.cfg.DueOnWorkingTime {
+		calendar, err := newCalendar(cli.cfg)
+		if err == nil {
+			return timer.ResolveWithin(time.Now(), calendar)
+		}
+		log.Errorf("Ignoring the working time: %s", err)
+	}
+	return timer.Resolve(time.Now())
+}
+
+// addWithoutEditor creates the todo as given, taking the title, if missing, and the details from stdin if asked for
+func (cli *cli) addWithoutEditor(entry todoModel, details string, fromStdin bool) {
+	entry.Details = details
+	if fromStdin {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Debugf("Error reading input from stdin: %v", err)
+		}
+		cleansedUserInput := cli.cleanseInput(string(input))
+		if len(entry.Title) == 0 && len(cleansedUserInput) > 0 {
+			entry.Title, cleansedUserInput = cli.parseDescriptionInput(cleansedUserInput)
+		}
+		if len(entry.Details) == 0 {
+			entry.Details = cleansedUserInput
+		}
+	}
+	if len(entry.Title) == 0 {
+		cli.Errorf("Skip creating due to an empty title.\n")
+		return
+	}
+	takeChecklist(&entry)
+	err := cli.app.add(entry)
 	if err != nil {
-		log.Debugf("Error processing input in editor: %v", err)
+		cli.Errorf("Could not create %s. Maybe this entry already exists? %s\n", entry.Title, err)
 	}
-	cleansedUserInput := cli.cleanseInput(editorUserInput)
+}
 
-	if len(cleansedUserInput) == 0 {
-		cli.Errorf("Skip creating %s due to an empty title.\n", title)
+// takeChecklist moves lines like '- [ ] item' out of the details into the checklist of the entry
+func takeChecklist(entry *todoModel) {
+	details, checklist := parseChecklist(entry.Details)
+	if len(checklist) > 0 {
+		entry.Details = strings.TrimSpace(details)
+		entry.Checklist = checklist
+	}
+}
+
+// addFromFile creates one todo per line of the file, each line given like the arguments of the add command
+func (cli *cli) addFromFile(file string) {
+	var content string
+	var err error
+	if file == "-" {
+		var data []byte
+		data, err = io.ReadAll(os.Stdin)
+		content = string(data)
 	} else {
-		userTitle, userDescription := cli.parseDescriptionInput(cleansedUserInput)
-		err := cli.app.add(userTitle, userDescription, due)
+		content, err = newFileReader(file).ReadString()
+	}
+	if err != nil {
+		cli.Errorf("Could not read %s: %s\n", file, err)
+		return
+	}
+	created := 0
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entry, err := cli.parseAddArguments(strings.Fields(line), false)
+		if err != nil {
+			cli.Errorf("Skip creating line %d %s: %s\n", i+1, entry.Title, err)
+			continue
+		}
+		err = cli.app.add(entry)
 		if err != nil {
-			cli.Errorf("Could not create %s. Maybe this entry already exists?\n", userTitle)
+			cli.Errorf("Could not create line %d %s. Maybe this entry already exists? %s\n", i+1, entry.Title, err)
+			continue
 		}
+		created++
 	}
+	cli.Resultf("Created %d todos from %s\n", created, file)
+}
 
+func isTerminal(file *os.File) bool {
+	stat, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
 }
 
-func (cli *cli) createDescriptionInput(title string, due time.Time) string {
+func (cli *cli) createDescriptionInput(title string, due time.Time, recurrence *recurrenceModel, tags []string, project string) string {
+	repeats := ""
+	if recurrence != nil {
+		repeats = fmt.Sprintf("# Repeats: %s\n", mapRecurrenceModel(recurrence))
+	}
+	if len(tags) > 0 || len(project) > 0 {
+		repeats += fmt.Sprintf("# Tags: %s\n", formatTags(tags, project))
+	}
 	return fmt.Sprintf(`%s
 # Please enter the title of your todo, adding a description after
 # an empty line if needed. Lines starting with '#' will be ignored,
-# and an empty input aborts this command.
+# and an empty input aborts this command. Lines like '- [ ] item'
+# in the description form a checklist.
 #
 # Title from command: %s
 # Due date of this todo: %s
-`, title, title, cli.format(due))
+%s`, title, title, cli.format(due), repeats)
+}
+
+func (cli *cli) edit(arguments []string) {
+	searchFor := strings.Join(arguments, " ")
+
+	var entry *todoModel
+
+	if len(searchFor) > 0 {
+		entry, _ = cli.app.find(searchFor)
+	}
+
+	if entry == nil {
+		cli.Errorf("No entry found matching %s\n", searchFor)
+		return
+	}
+
+	userInput := cli.createEditInput(*entry)
+	editorUserInput, err := cli.openInEditor(userInput)
+	if err != nil {
+		log.Debugf("Error processing input in editor: %v", err)
+	}
+	updated, err := cli.parseEditInput(editorUserInput, *entry)
+	if err != nil {
+		cli.Errorf("Skip editing %s: %s\n", entry.Title, err)
+		return
+	}
+	if updated.Title == entry.Title && updated.Details == entry.Details && updated.Due.Equal(entry.Due) && formatNotificationSetting(updated.Notification) == formatNotificationSetting(entry.Notification) &&
+		formatReminders(updated.Notification.Reminders) == formatReminders(entry.Notification.Reminders) &&
+		formatChecklist(updated.Checklist) == formatChecklist(entry.Checklist) && updated.AutoResolve == entry.AutoResolve {
+		cli.Resultf("Nothing changed for %s %s\n", entry.Id, entry.Title)
+		return
+	}
+	err = cli.app.update(updated)
+	if err != nil {
+		cli.Errorf("Could not update %s %s: %s\n", entry.Id, entry.Title, err)
+	} else {
+		cli.Resultf("Updated %s %s\n", updated.Id, updated.Title)
+	}
+}
+
+func (cli *cli) createEditInput(entry todoModel) string {
+	body := entry.Details
+	if len(entry.Checklist) > 0 {
+		if len(body) > 0 {
+			body += "\n\n"
+		}
+		body += strings.TrimSuffix(formatChecklist(entry.Checklist), "\n")
+	}
+	details := ""
+	if len(body) > 0 {
+		details = "\n" + body + "\n"
+	}
+	autoResolve := "no"
+	if entry.AutoResolve {
+		autoResolve = "yes"
+	}
+	return fmt.Sprintf(`%s
+%s
+# Please edit the title of your todo, adding a description after
+# an empty line if needed. Lines starting with '#' will be ignored,
+# and an empty input aborts this command.
+#
+# The due date accepts the same formats as the add command,
+# the notification is either 'none', 'once' or repeating after the
+# due date like 'repeat 30m' or 'repeat 30m escalate 3', escalating
+# after 3 repeats, and reminders are lead times before the due date
+# like '1d, 15m'. Lines like '- [ ] item' or '- [x] item' form the
+# checklist, autoresolve 'yes' resolving the todo once all are checked.
+due: %s
+notification: %s
+reminders: %s
+autoresolve: %s
+`, entry.Title, details, entry.Due.In(cli.location).Format("2006-01-02 15:04"), formatNotificationSetting(entry.Notification), formatReminders(entry.Notification.Reminders), autoResolve)
+}
+
+var editPropertyRegex = regexp.MustCompile("(?i)^(due|notification|reminders|autoresolve):[ \\t]*(.*)$")
+
+// splitEditInput separates the property lines following the last comment from the title, details and checklist
+// before it, so a details line like 'Due: waiting on vendor' stays part of the details. Without a comment left, the
+// trailing lines looking like properties are taken.
+func splitEditInput(input string) (body string, properties [][]string) {
+	lines := strings.Split(input, "\n")
+	start := len(lines)
+	for start > 0 && (editPropertyRegex.MatchString(lines[start-1]) || len(strings.TrimSpace(lines[start-1])) == 0) {
+		start--
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.HasPrefix(lines[i], "#") {
+			start = i + 1
+			break
+		}
+	}
+	bodyLines := append([]string{}, lines[:start]...)
+	for _, line := range lines[start:] {
+		if match := editPropertyRegex.FindStringSubmatch(line); match != nil {
+			properties = append(properties, match)
+		} else {
+			bodyLines = append(bodyLines, line)
+		}
+	}
+	return strings.Join(bodyLines, "\n"), properties
+}
+
+func (cli *cli) parseEditInput(input string, entry todoModel) (todoModel, error) {
+	body, properties := splitEditInput(input)
+	for _, match := range properties {
+		value := strings.TrimSpace(match[2])
+		if strings.EqualFold("due", match[1]) {
+			title, timer := ParseTimer(append([]string{"due"}, strings.Fields(value)...), cli.location)
+			if timer.Err() != nil {
+				return entry, timer.Err()
+			}
+			if timer.isEmpty() || title != "due" {
+				return entry, fmt.Errorf("due date %s not understood", value)
+			}
+			entry.Due = cli.resolveDue(timer)
+		} else if strings.EqualFold("reminders", match[1]) {
+			leadTimes := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+			_, err := parseReminders(leadTimes)
+			if err != nil {
+				return entry, err
+			}
+			entry.Notification.Reminders = reminderModelsOf(leadTimes)
+			if entry.Notification.Reminders == nil {
+				entry.Notification.Reminders = make([]reminderModel, 0)
+			}
+		} else if strings.EqualFold("autoresolve", match[1]) {
+			switch strings.ToLower(value) {
+			case "yes", "true", "on":
+				entry.AutoResolve = true
+			case "no", "false", "off", "":
+				entry.AutoResolve = false
+			default:
+				return entry, fmt.Errorf("autoresolve %s not understood, expecting yes or no", value)
+			}
+		} else {
+			setting, err := parseNotificationSetting(value)
+			if err != nil {
+				return entry, err
+			}
+			entry.Notification.Type = setting.Type
+			entry.Notification.Interval = setting.Interval
+			entry.Notification.EscalateAfter = setting.EscalateAfter
+		}
+	}
+	withoutChecklist, checklist := parseChecklist(body)
+	entry.Checklist = checklist
+	cleansedUserInput := cli.cleanseInput(withoutChecklist)
+	if len(cleansedUserInput) == 0 {
+		return entry, fmt.Errorf("empty title")
+	}
+	entry.Title, entry.Details = cli.parseDescriptionInput(cleansedUserInput)
+	return entry, nil
 }
 
 func (cli *cli) parseDescriptionInput(input string) (title string, description string) {
@@ -158,32 +524,110 @@ func (cli *cli) openInEditor(input string) (string, error) {
 	return result, nil
 }
 
-func (cli *cli) list() {
-	entries, idMap := cli.app.findAll()
+func (cli *cli) list(arguments []string) {
+	arguments, format, ok := cli.parseOutputArguments(arguments)
+	if !ok {
+		return
+	}
+	filter, mode, ok := cli.parseListArguments(arguments)
+	if !ok {
+		return
+	}
+	entries, idMap := cli.app.findAll(filter)
 
-	cli.printEntries(entries, idMap)
+	cli.printEntries(entries, idMap, mode, format)
 }
 
-func (cli *cli) due() {
-	entries, idMap := cli.app.findWhereDueBefore(time.Now())
+func (cli *cli) due(arguments []string) {
+	arguments, format, ok := cli.parseOutputArguments(arguments)
+	if !ok {
+		return
+	}
+	filter, mode, ok := cli.parseListArguments(arguments)
+	if !ok {
+		return
+	}
+	entries, idMap := cli.app.findWhereDueBefore(time.Now(), filter)
+
+	cli.printEntries(entries, idMap, mode, format)
+}
 
-	cli.printEntries(entries, idMap)
+func (cli *cli) parseOutputArguments(arguments []string) ([]string, outputFormat, bool) {
+	remaining, format, err := ParseOutputFormat(arguments)
+	if err != nil {
+		cli.Errorf("Could not output: %s\n", err)
+		return arguments, format, false
+	}
+	if format == OutputFormatPlain {
+		color.NoColor = true
+	}
+	return remaining, format, true
 }
 
-func (cli *cli) printEntries(entries []todoModel, idMap ShortIdMap) {
-	for _, entry := range sorted(entries) {
+func (cli *cli) parseListArguments(arguments []string) (todoFilter, sortMode, bool) {
+	remaining, tags, project := ParseTags(arguments)
+	mode, err := parseSortMode(cli.cfg.SortMode)
+	ready := false
+	unknown := make([]string, 0)
+	for _, argument := range remaining {
+		if len(argument) > len("sort:") && strings.EqualFold("sort:", argument[:len("sort:")]) {
+			mode, err = parseSortMode(argument[len("sort:"):])
+		} else if argument == "--ready" {
+			ready = true
+		} else {
+			unknown = append(unknown, argument)
+		}
+	}
+	if err != nil {
+		cli.Errorf("Could not sort: %s\n", err)
+		return todoFilter{}, mode, false
+	}
+	// the remaining arguments form a filter expression, like 'due<2026-11-01 and title~deploy and not notified'
+	query := strings.Join(unknown, " ")
+	if _, err := parseTodoQuery(query, cli.location); err != nil {
+		cli.Errorf("Could not filter by %s: %s\n", query, err)
+		return todoFilter{}, mode, false
+	}
+	return todoFilter{Tags: tags, Project: project, Ready: ready, Query: query, Location: cli.location}, mode, true
+}
+
+func (cli *cli) printEntries(entries []todoModel, idMap ShortIdMap, mode sortMode, format outputFormat) {
+	if format.isMachineReadable() {
+		cli.writeMachineReadable(writeEntries(cli.stdout, format, sorted(entries, mode), idMap))
+		return
+	}
+	for _, entry := range sorted(entries, mode) {
 		blue := color.New(color.FgBlue).SprintFunc()
 		magenta := color.New(color.FgMagenta).SprintFunc()
 		green := color.New(color.FgGreen).SprintFunc()
+		cyan := color.New(color.FgCyan).SprintFunc()
 		dueFunc := green
 		if entry.Due.Before(time.Now()) {
 			dueFunc = magenta
 		}
-		cli.Resultf("[%s] %s %s\n", blue(idMap[entry.Id.String()]), entry.Title, dueFunc(cli.formatRelativeTo(entry.Due, time.Now())))
+		title := entry.Title
+		if len(entry.Priority) > 0 {
+			title = priorityColor(entry.Priority)("!"+entry.Priority) + " " + title
+		}
+		if len(entry.Checklist) > 0 {
+			done, total := checklistProgress(entry.Checklist)
+			title += fmt.Sprintf(" [%d/%d]", done, total)
+		}
+		if len(entry.Tags) > 0 || len(entry.Project) > 0 {
+			title += " " + cyan(formatTags(entry.Tags, entry.Project))
+		}
+		if entry.Blocked {
+			title += " " + magenta("(blocked)")
+		}
+		cli.Resultf("[%s] %s %s\n", blue(idMap[entry.Id.String()]), title, dueFunc(cli.formatRelativeTo(entry.Due, time.Now())))
 	}
 }
 
 func (cli *cli) show(arguments []string) {
+	arguments, format, ok := cli.parseOutputArguments(arguments)
+	if !ok {
+		return
+	}
 	searchFor := ""
 	buffer := &bytes.Buffer{}
 	for i, argument := range arguments {
@@ -203,20 +647,109 @@ func (cli *cli) show(arguments []string) {
 
 	if entry == nil {
 		cli.Errorf("No entry found matching %s\n", searchFor)
+	} else if format.isMachineReadable() {
+		cli.writeMachineReadable(writeEntry(cli.stdout, format, *entry, entryId))
 	} else {
-		blue := color.New(color.FgBlue).SprintFunc()
-		magenta := color.New(color.FgMagenta).SprintFunc()
-		green := color.New(color.FgGreen).SprintFunc()
-		dueFunc := green
-		if entry.Due.Before(time.Now()) {
-			dueFunc = magenta
+		cli.Resultf("%s", cli.formatDetails(entry, entryId))
+	}
+}
+
+// formatDetails renders the detail view of a todo as printed by show
+func (cli *cli) formatDetails(entry *todoModel, entryId string) string {
+	blue := color.New(color.FgBlue).SprintFunc()
+	magenta := color.New(color.FgMagenta).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	dueFunc := green
+	if entry.Due.Before(time.Now()) {
+		dueFunc = magenta
+	}
+	cyan := color.New(color.FgCyan).SprintFunc()
+	tags := ""
+	if len(entry.Priority) > 0 {
+		tags = priorityColor(entry.Priority)("!"+entry.Priority) + "\n"
+	}
+	if len(entry.Tags) > 0 || len(entry.Project) > 0 {
+		tags += cyan(formatTags(entry.Tags, entry.Project)) + "\n"
+	}
+	details := ""
+	if len(entry.Details) > 0 {
+		details = entry.Details + "\n"
+	}
+	for i, item := range entry.Checklist {
+		mark := " "
+		if item.Done {
+			mark = "x"
 		}
-		details := ""
-		if len(entry.Details) > 0 {
-			details = entry.Details + "\n"
+		details += fmt.Sprintf("%d. [%s] %s\n", i+1, mark, item.Text)
+	}
+	reminders := ""
+	if len(entry.Notification.Reminders) > 0 {
+		reminders = fmt.Sprintf("Reminds %s before\n", formatReminders(entry.Notification.Reminders))
+	}
+	if entry.Notification.Type == string(NotificationTypeRepeat) {
+		reminders += fmt.Sprintf("Notifies every %s after due", entry.Notification.Interval)
+		if entry.Notification.EscalateAfter > 0 {
+			reminders += fmt.Sprintf(", escalating after %d repeats", entry.Notification.EscalateAfter)
 		}
-		cli.Resultf("[%s]\n%s\n%s\n%s", blue(entryId), entry.Title, dueFunc(cli.format(entry.Due)), details)
+		reminders += "\n"
+	}
+	if len(entry.Notification.History) > 0 {
+		reminders += fmt.Sprintf("Notified %d times, last %s\n", len(entry.Notification.History), cli.format(entry.Notification.NotifiedAt))
+	}
+	return fmt.Sprintf("[%s]\n%s\n%s%s\n%s%s%s%s", blue(entryId), entry.Title, tags, dueFunc(cli.format(entry.Due)), reminders, cli.formatRecurrence(entry), cli.formatBlockers(entry), details)
+}
+
+// formatBlockers renders the chain of todos the entry is waiting for, indenting the blockers of each blocker
+func (cli *cli) formatBlockers(entry *todoModel) string {
+	if len(entry.BlockedBy) == 0 {
+		return ""
+	}
+	entries, idMap := cli.app.findAll(todoFilter{})
+	active := make(map[uuid.UUID]todoModel, len(entries))
+	for _, e := range entries {
+		active[e.Id] = e
 	}
+	builder := &strings.Builder{}
+	builder.WriteString("Blocked by:\n")
+	cli.writeBlockers(builder, entry.BlockedBy, active, idMap, map[uuid.UUID]bool{entry.Id: true}, 1)
+	return builder.String()
+}
+
+func (cli *cli) writeBlockers(builder *strings.Builder, blockedBy []uuid.UUID, active map[uuid.UUID]todoModel, idMap ShortIdMap, visited map[uuid.UUID]bool, depth int) {
+	blue := color.New(color.FgBlue).SprintFunc()
+	indent := strings.Repeat("  ", depth)
+	for _, blockerId := range blockedBy {
+		blocker, ok := active[blockerId]
+		if !ok {
+			title := blockerId.String()
+			archived, _ := cli.app.findInArchive(blockerId.String())
+			if archived != nil {
+				title = archived.Title
+			}
+			builder.WriteString(fmt.Sprintf("%s%s (resolved)\n", indent, title))
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("%s[%s] %s\n", indent, blue(idMap[blockerId.String()]), blocker.Title))
+		if !visited[blockerId] {
+			visited[blockerId] = true
+			cli.writeBlockers(builder, blocker.BlockedBy, active, idMap, visited, depth+1)
+		}
+	}
+}
+
+func (cli *cli) formatRecurrence(entry *todoModel) string {
+	if entry.Recurrence == nil {
+		return ""
+	}
+	recurrence := mapRecurrenceModel(entry.Recurrence)
+	next := make([]string, 0)
+	for _, occurrence := range recurrence.occurrences(entry.Due, 3) {
+		next = append(next, cli.format(occurrence.In(cli.location)))
+	}
+	if len(next) == 0 {
+		return fmt.Sprintf("Repeats %s, this is the last occurrence\n", recurrence)
+	}
+	return fmt.Sprintf("Repeats %s, next: %s\n", recurrence, strings.Join(next, "; "))
 }
 
 func (cli *cli) del(arguments []string) {
@@ -230,15 +763,9 @@ func (cli *cli) del(arguments []string) {
 	}
 	searchFor = buffer.String()
 
-	var entry *todoModel
-
-	if len(searchFor) > 0 {
-		entry, _ = cli.app.find(searchFor)
-	}
+	entry := cli.findOne(searchFor)
 
-	if entry == nil {
-		cli.Errorf("No entry found matching %s\n", searchFor)
-	} else {
+	if entry != nil {
 		err := cli.app.delete(entry.Id)
 		if err != nil {
 			cli.Errorf("Could not delete %s %s: %s", entry.Id, entry.Title, err)
@@ -260,15 +787,9 @@ func (cli *cli) resolve(arguments []string) {
 	}
 	searchFor = buffer.String()
 
-	var entry *todoModel
+	entry := cli.findOne(searchFor)
 
-	if len(searchFor) > 0 {
-		entry, _ = cli.app.find(searchFor)
-	}
-
-	if entry == nil {
-		cli.Errorf("No entry found matching %s\n", searchFor)
-	} else {
+	if entry != nil {
 		err := cli.app.resolve(entry.Id)
 		if err != nil {
 			cli.Errorf("Could not resolve %s %s: %s\n", entry.Id, entry.Title, err)
@@ -281,35 +802,566 @@ func (cli *cli) resolve(arguments []string) {
 func (cli *cli) snooze(arguments []string) {
 	var newDue time.Time
 	searchFor, timer := ParseTimer(arguments, cli.location)
+	if timer.Err() != nil {
+		cli.Errorf("Could not snooze %s: %s\n", searchFor, timer.Err())
+		return
+	}
 	if !timer.isEmpty() {
-		newDue = timer.Resolve(time.Now())
+		newDue = cli.resolveDue(timer)
 	} else {
 		newDue = time.Now().Add(1 * time.Hour)
 	}
 
+	entry := cli.findOne(searchFor)
+
+	if entry != nil {
+		err := cli.app.setNewDue(entry.Id, newDue)
+		if err != nil {
+			cli.Errorf("Could not snooze %s %s: %s", entry.Id, entry.Title, err)
+		} else {
+			cli.Resultf("Snoozed %s %s\n", entry.Id, entry.Title)
+		}
+	}
+}
+
+// findOne finds the todo to be deleted, resolved or snoozed. When several todos match equally well, they are offered
+// to choose from if stdin is a terminal and listed otherwise, returning nil unless one is chosen.
+func (cli *cli) findOne(searchFor string) *todoModel {
+	var candidates []todoModel
+	var idMap ShortIdMap
+	if len(searchFor) > 0 {
+		candidates, idMap = cli.app.findMatching(searchFor)
+	}
+	if len(candidates) == 0 {
+		cli.Errorf("No entry found matching %s\n", searchFor)
+		return nil
+	}
+	if isUnambiguousMatch(candidates, idMap, searchFor) {
+		return &candidates[0]
+	}
+	return cli.choose(candidates, idMap, searchFor, os.Stdin, isTerminal(os.Stdin))
+}
+
+// choose lets the user pick one of the candidates by its number, refusing to pick one when not interactive
+func (cli *cli) choose(candidates []todoModel, idMap ShortIdMap, searchFor string, in io.Reader, interactive bool) *todoModel {
+	if !interactive {
+		cli.Errorf("%d entries found matching %s, be more specific or use one of the ids:\n", len(candidates), searchFor)
+		for _, candidate := range candidates {
+			cli.Errorf("  [%s] %s %s\n", idMap[candidate.Id.String()], candidate.Title, cli.formatRelativeTo(candidate.Due, time.Now()))
+		}
+		return nil
+	}
+	cli.Errorf("%d entries found matching %s:\n", len(candidates), searchFor)
+	for i, candidate := range candidates {
+		cli.Errorf("  %d) [%s] %s %s\n", i+1, idMap[candidate.Id.String()], candidate.Title, cli.formatRelativeTo(candidate.Due, time.Now()))
+	}
+	cli.Errorf("Choose 1-%d or press enter to abort: ", len(candidates))
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	chosen, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || chosen < 1 || chosen > len(candidates) {
+		cli.Errorf("Aborted, no entry chosen\n")
+		return nil
+	}
+	return &candidates[chosen-1]
+}
+
+func (cli *cli) archive(arguments []string) {
+	arguments, format, ok := cli.parseOutputArguments(arguments)
+	if !ok {
+		return
+	}
+	if len(arguments) > 2 {
+		cli.Errorf("Usage: archive [<from 2006-01-02> [<to 2006-01-02>]]\n")
+		return
+	}
+	var resolvedFrom, resolvedTo time.Time
+	for i, argument := range arguments {
+		day, err := time.ParseInLocation("2006-01-02", argument, cli.location)
+		if err != nil {
+			cli.Errorf("Could not parse date %s, expecting the format 2006-01-02\n", argument)
+			return
+		}
+		if i == 0 {
+			resolvedFrom = day
+		} else {
+			resolvedTo = day.AddDate(0, 0, 1)
+		}
+	}
+
+	entries, idMap := cli.app.findArchived(resolvedFrom, resolvedTo)
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ResolvedAt.After(entries[j].ResolvedAt)
+	})
+	if format.isMachineReadable() {
+		cli.writeMachineReadable(writeEntries(cli.stdout, format, entries, idMap))
+		return
+	}
+	blue := color.New(color.FgBlue).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	for _, entry := range entries {
+		cli.Resultf("[%s] %s %s\n", blue(idMap[entry.Id.String()]), entry.Title, green("resolved "+cli.format(entry.ResolvedAt.In(cli.location))))
+	}
+}
+
+func (cli *cli) reopen(arguments []string) {
+	searchFor := strings.Join(arguments, " ")
+
 	var entry *todoModel
 
 	if len(searchFor) > 0 {
-		entry, _ = cli.app.find(searchFor)
+		entry, _ = cli.app.findInArchive(searchFor)
 	}
 
+	if entry == nil {
+		cli.Errorf("No archived entry found matching %s\n", searchFor)
+	} else {
+		err := cli.app.reopen(entry.Id)
+		if err != nil {
+			cli.Errorf("Could not reopen %s %s: %s\n", entry.Id, entry.Title, err)
+		} else {
+			cli.Resultf("Reopened %s %s\n", entry.Id, entry.Title)
+		}
+	}
+}
+
+func (cli *cli) prio(arguments []string) {
+	if len(arguments) < 2 {
+		cli.Errorf("Usage: prio <search> <high|medium|low|none>\n")
+		return
+	}
+	newPriority := arguments[len(arguments)-1]
+	if strings.EqualFold("none", newPriority) {
+		newPriority = ""
+	}
+	searchFor := strings.Join(arguments[:len(arguments)-1], " ")
+
+	entry, _ := cli.app.find(searchFor)
+
 	if entry == nil {
 		cli.Errorf("No entry found matching %s\n", searchFor)
 	} else {
-		err := cli.app.setNewDue(entry.Id, newDue)
+		err := cli.app.setPriority(entry.Id, newPriority)
 		if err != nil {
-			cli.Errorf("Could not snooze %s %s: %s", entry.Id, entry.Title, err)
+			cli.Errorf("Could not prioritize %s %s: %s\n", entry.Id, entry.Title, err)
 		} else {
-			cli.Resultf("Snoozed %s %s\n", entry.Id, entry.Title)
+			cli.Resultf("Prioritized %s %s\n", entry.Id, entry.Title)
+		}
+	}
+}
+
+func (cli *cli) item(arguments []string) {
+	if len(arguments) < 2 {
+		cli.Errorf("Usage: item <todo> <text>\n")
+		return
+	}
+	searchFor := arguments[0]
+	text := strings.Join(arguments[1:], " ")
+
+	entry, _ := cli.app.find(searchFor)
+
+	if entry == nil {
+		cli.Errorf("No entry found matching %s\n", searchFor)
+	} else {
+		err := cli.app.addChecklistItem(entry.Id, text)
+		if err != nil {
+			cli.Errorf("Could not add checklist item to %s %s: %s\n", entry.Id, entry.Title, err)
+		} else {
+			cli.Resultf("Added item %d to %s %s\n", len(entry.Checklist)+1, entry.Id, entry.Title)
 		}
 	}
 }
 
+func (cli *cli) check(arguments []string, done bool) {
+	command := "check"
+	if !done {
+		command = "uncheck"
+	}
+	if len(arguments) < 2 {
+		cli.Errorf("Usage: %s <search> <index>\n", command)
+		return
+	}
+	index, err := strconv.Atoi(arguments[len(arguments)-1])
+	if err != nil {
+		cli.Errorf("Usage: %s <search> <index>\n", command)
+		return
+	}
+	searchFor := strings.Join(arguments[:len(arguments)-1], " ")
+
+	entry, _ := cli.app.find(searchFor)
+
+	if entry == nil {
+		cli.Errorf("No entry found matching %s\n", searchFor)
+		return
+	}
+	err = cli.app.checkChecklistItem(entry.Id, index, done)
+	if err != nil {
+		cli.Errorf("Could not %s item %d of %s %s: %s\n", command, index, entry.Id, entry.Title, err)
+		return
+	}
+	if index >= 1 && index <= len(entry.Checklist) {
+		entry.Checklist[index-1].Done = done
+	}
+	doneCount, total := checklistProgress(entry.Checklist)
+	if done && entry.AutoResolve && doneCount == total {
+		cli.Resultf("Checked item %d, resolved %s %s\n", index, entry.Id, entry.Title)
+	} else if done {
+		cli.Resultf("Checked item %d of %s %s [%d/%d]\n", index, entry.Id, entry.Title, doneCount, total)
+	} else {
+		cli.Resultf("Unchecked item %d of %s %s [%d/%d]\n", index, entry.Id, entry.Title, doneCount, total)
+	}
+}
+
+func (cli *cli) block(arguments []string, blocking bool) {
+	command := "block"
+	if !blocking {
+		command = "unblock"
+	}
+	separator := -1
+	for i, argument := range arguments {
+		if strings.EqualFold("by", argument) {
+			separator = i
+		}
+	}
+	if separator < 1 || separator == len(arguments)-1 {
+		cli.Errorf("Usage: %s <todo> by <blocker>\n", command)
+		return
+	}
+	searchFor := strings.Join(arguments[:separator], " ")
+	searchForBlocker := strings.Join(arguments[separator+1:], " ")
+
+	entry, _ := cli.app.find(searchFor)
+	if entry == nil {
+		cli.Errorf("No entry found matching %s\n", searchFor)
+		return
+	}
+	blockerId := uuid.Nil
+	blockerTitle := ""
+	blocker, _ := cli.app.find(searchForBlocker)
+	if blocker != nil {
+		blockerId, blockerTitle = blocker.Id, blocker.Title
+	} else if !blocking {
+		archived, _ := cli.app.findInArchive(searchForBlocker)
+		if archived != nil {
+			blockerId, blockerTitle = archived.Id, archived.Title
+		}
+	}
+	if blockerId == uuid.Nil {
+		cli.Errorf("No entry found matching %s\n", searchForBlocker)
+		return
+	}
+
+	if blocking {
+		err := cli.app.block(entry.Id, blockerId)
+		if err != nil {
+			cli.Errorf("Could not block %s %s: %s\n", entry.Id, entry.Title, err)
+		} else {
+			cli.Resultf("Blocked %s %s by %s %s\n", entry.Id, entry.Title, blockerId, blockerTitle)
+		}
+	} else {
+		err := cli.app.unblock(entry.Id, blockerId)
+		if err != nil {
+			cli.Errorf("Could not unblock %s %s: %s\n", entry.Id, entry.Title, err)
+		} else {
+			cli.Resultf("Unblocked %s %s from %s %s\n", entry.Id, entry.Title, blockerId, blockerTitle)
+		}
+	}
+}
+
+func (cli *cli) watch() {
+	green := color.New(color.FgGreen).SprintFunc()
+	blue := color.New(color.FgBlue).SprintFunc()
+	magenta := color.New(color.FgMagenta).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	colors := map[string]func(a ...interface{}) string{string(ChangeTypeCreated): green, string(ChangeTypeUpdated): blue, string(ChangeTypeResolved): green, string(ChangeTypeDeleted): magenta, string(ChangeTypeNotified): cyan}
+	err := cli.app.watch("", func(event changeEvent) {
+		typeColor, ok := colors[event.Type]
+		if !ok {
+			typeColor = fmt.Sprint
+		}
+		cli.Resultf("%s %s %s %s\n", cli.format(event.At.In(cli.location)), typeColor(fmt.Sprintf("%-8s", event.Type)), event.TodoId, event.Todo.Title)
+	})
+	if err != nil {
+		cli.Errorf("Could not watch: %s\n", err)
+	}
+}
+
+func (cli *cli) export(arguments []string) {
+	var format *exportFormat
+	archived := false
+	component := IcsComponentTodo
+	remaining := make([]string, 0, len(arguments))
+	for i := 0; i < len(arguments); i++ {
+		argument := arguments[i]
+		value := ""
+		if argument == "--format" || argument == "-f" {
+			if i+1 >= len(arguments) {
+				cli.Errorf("Could not export: format missing\n")
+				return
+			}
+			value = arguments[i+1]
+			i++
+		} else if strings.HasPrefix(argument, "--format=") {
+			value = argument[len("--format="):]
+		} else if argument == "--archived" {
+			archived = true
+			continue
+		} else if argument == "--events" {
+			component = IcsComponentEvent
+			continue
+		} else {
+			remaining = append(remaining, argument)
+			continue
+		}
+		parsed, err := parseExportFormat(value)
+		if err != nil {
+			cli.Errorf("Could not export: %s\n", err)
+			return
+		}
+		format = &parsed
+	}
+	if format == nil {
+		cli.Errorf("Usage: export --format ics|todotxt [--archived] [--events] [+tag] [@context] [project:name]\n")
+		return
+	}
+	filter, _, ok := cli.parseListArguments(remaining)
+	if !ok {
+		return
+	}
+	switch *format {
+	case ExportFormatIcs:
+		entries := collectExportEntries(cli.app, filter, archived)
+		cli.writeMachineReadable(writeIcs(cli.stdout, entries, component, time.Now()))
+	case ExportFormatTodoTxt:
+		// a todo.txt file holds the completed todos as well, so the archive is always part of it
+		entries := collectExportEntries(cli.app, filter, true)
+		cli.writeMachineReadable(writeTodoTxt(cli.stdout, entries))
+	}
+}
+
+func (cli *cli) report(arguments []string) {
+	format := ReportFormatMarkdown
+	sections := ""
+	window := ""
+	remaining := make([]string, 0, len(arguments))
+	for i := 0; i < len(arguments); i++ {
+		argument := arguments[i]
+		if (argument == "--format" || argument == "--sections" || argument == "--window") && i+1 >= len(arguments) {
+			cli.Errorf("Could not report: value of %s missing\n", argument)
+			return
+		}
+		switch argument {
+		case "--format":
+			parsed, err := parseReportFormat(arguments[i+1])
+			if err != nil {
+				cli.Errorf("Could not report: %s\n", err)
+				return
+			}
+			format = parsed
+			i++
+		case "--sections":
+			sections = arguments[i+1]
+			i++
+		case "--window":
+			window = arguments[i+1]
+			i++
+		default:
+			remaining = append(remaining, argument)
+		}
+	}
+	options, err := parseReportOptions(cli.cfg.ReportSections, cli.cfg.ReportWindow)
+	if err == nil {
+		options, err = overrideReportOptions(options, sections, window)
+	}
+	if err != nil {
+		cli.Errorf("Could not report: %s\n", err)
+		return
+	}
+	filter, _, ok := cli.parseListArguments(remaining)
+	if !ok {
+		return
+	}
+	data := buildReport(cli.app, filter, options, time.Now(), cli.location)
+	cli.writeMachineReadable(writeReport(cli.stdout, data, format))
+}
+
+// backup writes all active and archived todos into the file, or to stdout without a file or with '-'
+func (cli *cli) backup(arguments []string) {
+	file := "-"
+	var format *backupFormat
+	for i := 0; i < len(arguments); i++ {
+		if arguments[i] == "--format" {
+			if i+1 >= len(arguments) {
+				cli.Errorf("Could not back up: format missing\n")
+				return
+			}
+			parsed, err := parseBackupFormat(arguments[i+1])
+			if err != nil {
+				cli.Errorf("Could not back up: %s\n", err)
+				return
+			}
+			format = &parsed
+			i++
+		} else {
+			file = arguments[i]
+		}
+	}
+	if format == nil {
+		byFile := backupFormatOfFile(file)
+		format = &byFile
+	}
+	bundle, err := cli.app.backup()
+	if err != nil {
+		cli.Errorf("Could not back up: %s\n", err)
+		return
+	}
+	if file == "-" {
+		cli.writeMachineReadable(writeBackup(cli.stdout, bundle, *format))
+		return
+	}
+	buffer := &bytes.Buffer{}
+	err = writeBackup(buffer, bundle, *format)
+	if err == nil {
+		err = os.WriteFile(file, buffer.Bytes(), 0600)
+	}
+	if err != nil {
+		cli.Errorf("Could not back up into %s: %s\n", file, err)
+		return
+	}
+	cli.Resultf("Backed up %d active and %d archived todos into %s\n", len(bundle.Todos), len(bundle.Archive), file)
+}
+
+// restore reads a backup from the file or from stdin given as '-', merging it into the todos present or with
+// '--replace' replacing all of them
+func (cli *cli) restore(arguments []string) {
+	file := ""
+	mode := RestoreModeMerge
+	for _, argument := range arguments {
+		if argument == "--replace" {
+			mode = RestoreModeReplace
+		} else if argument == "--merge" {
+			mode = RestoreModeMerge
+		} else {
+			file = argument
+		}
+	}
+	if len(file) == 0 {
+		cli.Errorf("Usage: restore [--merge|--replace] <file|->\n")
+		return
+	}
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		cli.Errorf("Could not read %s: %s\n", file, err)
+		return
+	}
+	bundle, err := readBackup(data)
+	if err != nil {
+		cli.Errorf("Could not restore %s: %s\n", file, err)
+		return
+	}
+	result, err := cli.app.restore(bundle, mode)
+	if err != nil {
+		cli.Errorf("Could not restore %s: %s\n", file, err)
+		return
+	}
+	for _, conflict := range result.Conflicts {
+		cli.Errorf("Conflict on %s %s: %s\n", conflict.Id, conflict.Title, conflict.Reason)
+	}
+	removed := ""
+	if mode == RestoreModeReplace {
+		removed = fmt.Sprintf(", replacing %d todos", result.Removed)
+	}
+	cli.Resultf("Restored %d active and %d archived todos from %s%s, %d conflicts\n", result.Restored, result.Archived, file, removed, len(result.Conflicts))
+}
+
+// importFile adds the todos of a todo.txt file or of stdin given as '-', skipping those with a title already present
+// among the active or the resolved todos, so importing the same file again adds nothing
+func (cli *cli) importFile(arguments []string) {
+	file := ""
+	formatGiven := false
+	for i := 0; i < len(arguments); i++ {
+		value := ""
+		if (arguments[i] == "--format" || arguments[i] == "-f") && i+1 < len(arguments) {
+			value = arguments[i+1]
+			i++
+		} else if strings.HasPrefix(arguments[i], "--format=") {
+			value = arguments[i][len("--format="):]
+		} else {
+			file = arguments[i]
+			continue
+		}
+		if format, err := parseExportFormat(value); err != nil || format != ExportFormatTodoTxt {
+			cli.Errorf("Could not import: format %s unknown, expecting todotxt.\n", value)
+			return
+		}
+		formatGiven = true
+	}
+	if !formatGiven || len(file) == 0 {
+		cli.Errorf("Usage: import --format todotxt <file|->\n")
+		return
+	}
+	var content string
+	var err error
+	if file == "-" {
+		var data []byte
+		data, err = io.ReadAll(os.Stdin)
+		content = string(data)
+	} else {
+		content, err = newFileReader(file).ReadString()
+	}
+	if err != nil {
+		cli.Errorf("Could not read %s: %s\n", file, err)
+		return
+	}
+	entries, err := readTodoTxt(strings.NewReader(content), cli.location, time.Now())
+	if err != nil {
+		cli.Errorf("Could not import %s: %s\n", file, err)
+		return
+	}
+	present := make(map[string]bool)
+	for _, entry := range collectExportEntries(cli.app, todoFilter{}, true) {
+		present[strings.ToLower(entry.Title)] = true
+	}
+	created := 0
+	skipped := 0
+	for _, entry := range entries {
+		if present[strings.ToLower(entry.Title)] {
+			skipped++
+			continue
+		}
+		err = cli.app.add(entry)
+		if err != nil {
+			cli.Errorf("Could not import %s: %s\n", entry.Title, err)
+			continue
+		}
+		present[strings.ToLower(entry.Title)] = true
+		created++
+	}
+	cli.Resultf("Imported %d todos from %s, skipped %d already present\n", created, file, skipped)
+}
+
+func (cli *cli) writeMachineReadable(err error) {
+	if err != nil {
+		cli.Errorf("Could not write output: %s\n", err)
+	}
+}
+
 func (cli *cli) format(timestamp time.Time) string {
 	return timestamp.Format(cli.timeRenderLayout)
 }
 
 func (cli *cli) formatRelativeTo(timestamp, relativeTimestamp time.Time) string {
+	return formatRelativeTimestamp(timestamp, relativeTimestamp)
+}
+
+// formatRelativeTimestamp tells when the timestamp is seen from the relative timestamp, like 'in 2 days' or 'since yesterday'
+func formatRelativeTimestamp(timestamp, relativeTimestamp time.Time) string {
 	dueIn := timestamp.Sub(relativeTimestamp)
 	if dueIn >= 0 {
 		if dueIn <= 12*time.Hour {
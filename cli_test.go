@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -13,6 +15,172 @@ func TestParseDescriptionInput(t *testing.T) {
 	assertEquals(t, "", description)
 }
 
+func TestParseEditInput_roundTrip(t *testing.T) {
+	cli := cli{location: locationBerlin()}
+	due, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	entry := todoModel{Title: "Testtitle", Details: "Some\n\ndetails", Due: due, Notification: notificationModel{Type: "once"}}
+	parsed, err := cli.parseEditInput(cli.createEditInput(entry), entry)
+	assertTrue(t, err == nil)
+	assertEquals(t, "Testtitle", parsed.Title)
+	assertEquals(t, "Some\n\ndetails", parsed.Details)
+	assertEquals(t, "2023-11-18T14:00:00+01:00", parsed.Due.Format(time.RFC3339))
+	assertEquals(t, "once", parsed.Notification.Type)
+}
+
+func TestParseEditInput_changedValues(t *testing.T) {
+	cli := cli{location: locationBerlin()}
+	entry := todoModel{Title: "Testtitle", Notification: notificationModel{Type: "once"}}
+	parsed, err := cli.parseEditInput("New title\n\nNew details\n# comment\ndue: 2023-12-24 18:00\nnotification: None\n", entry)
+	assertTrue(t, err == nil)
+	assertEquals(t, "New title", parsed.Title)
+	assertEquals(t, "New details", parsed.Details)
+	assertEquals(t, "2023-12-24T18:00:00+01:00", parsed.Due.Format(time.RFC3339))
+	assertEquals(t, "none", parsed.Notification.Type)
+}
+
+func TestParseEditInput_detailsLineLikeProperty(t *testing.T) {
+	cli := cli{location: locationBerlin()}
+	due := time.Date(2023, 12, 24, 18, 0, 0, 0, locationBerlin())
+	entry := todoModel{Title: "Order parts", Details: "Due: waiting on vendor", Due: due, Notification: notificationModel{Type: "once"}}
+	parsed, err := cli.parseEditInput(cli.createEditInput(entry), entry)
+	assertTrue(t, err == nil)
+	assertEquals(t, "Order parts", parsed.Title)
+	assertEquals(t, "Due: waiting on vendor", parsed.Details)
+	assertTrue(t, parsed.Due.Equal(due))
+
+	parsed, err = cli.parseEditInput("Order parts\n\ndue: waiting on vendor\n# comment\ndue: 2023-12-25 10:00\n", entry)
+	assertTrue(t, err == nil)
+	assertEquals(t, "due: waiting on vendor", parsed.Details)
+	assertEquals(t, "2023-12-25T10:00:00+01:00", parsed.Due.Format(time.RFC3339))
+}
+
+func TestParseEditInput_reminders(t *testing.T) {
+	cli := cli{location: locationBerlin()}
+	entry := todoModel{Title: "Testtitle", Notification: notificationModel{Reminders: reminderModelsOf([]string{"1d"})}}
+	parsed, err := cli.parseEditInput(cli.createEditInput(entry), entry)
+	assertTrue(t, err == nil)
+	assertEquals(t, "1d", formatReminders(parsed.Notification.Reminders))
+	parsed, err = cli.parseEditInput("Testtitle\nreminders: 2h, 15m\n", entry)
+	assertTrue(t, err == nil)
+	assertEquals(t, "2h, 15m", formatReminders(parsed.Notification.Reminders))
+	parsed, err = cli.parseEditInput("Testtitle\nreminders:\n", entry)
+	assertTrue(t, err == nil)
+	assertTrue(t, parsed.Notification.Reminders != nil && len(parsed.Notification.Reminders) == 0)
+	_, err = cli.parseEditInput("Testtitle\nreminders: soon\n", entry)
+	assertTrue(t, err != nil)
+}
+
+func TestParseEditInput_checklist(t *testing.T) {
+	cli := cli{location: locationBerlin()}
+	entry := todoModel{Title: "Testtitle", Details: "Some details", Checklist: []checklistItemModel{{Text: "first", Done: true}, {Text: "second"}}, AutoResolve: true}
+	parsed, err := cli.parseEditInput(cli.createEditInput(entry), entry)
+	assertTrue(t, err == nil)
+	assertEquals(t, "Some details", parsed.Details)
+	assertEquals(t, formatChecklist(entry.Checklist), formatChecklist(parsed.Checklist))
+	assertTrue(t, parsed.AutoResolve)
+	parsed, err = cli.parseEditInput("Testtitle\nautoresolve: no\n", entry)
+	assertTrue(t, err == nil)
+	assertTrue(t, parsed.Checklist != nil && len(parsed.Checklist) == 0)
+	assertFalse(t, parsed.AutoResolve)
+}
+
+func TestParseEditInput_invalidDue(t *testing.T) {
+	cli := cli{location: locationBerlin()}
+	_, err := cli.parseEditInput("Testtitle\ndue: someday\n", todoModel{})
+	assertTrue(t, err != nil)
+}
+
+func TestParseAddOptions(t *testing.T) {
+	arguments, options, err := parseAddOptions([]string{"title", "--no-edit", "-m", "some details", "tomorrow"})
+	assertTrue(t, err == nil)
+	assertEquals(t, "title tomorrow", strings.Join(arguments, " "))
+	assertTrue(t, options.noEdit)
+	assertEquals(t, "some details", options.details)
+	assertEquals(t, "", options.file)
+}
+
+func TestParseAddOptions_missingValue(t *testing.T) {
+	_, _, err := parseAddOptions([]string{"--file"})
+	assertTrue(t, err != nil)
+}
+
+func TestParseAddArguments(t *testing.T) {
+	cli := cli{location: locationBerlin()}
+	entry, err := cli.parseAddArguments([]string{"call", "bob", "+phone", "!high", "in", "2h"}, false)
+	assertTrue(t, err == nil)
+	assertEquals(t, "call bob", entry.Title)
+	assertEquals(t, "phone", strings.Join(entry.Tags, " "))
+	assertEquals(t, "high", entry.Priority)
+	assertTrue(t, entry.Due.After(time.Now().Add(time.Hour)))
+}
+
+func TestParseAddArguments_dueOnlyWithTitleElsewhere(t *testing.T) {
+	cli := cli{location: locationBerlin()}
+	entry, err := cli.parseAddArguments([]string{"tomorrow"}, true)
+	assertTrue(t, err == nil)
+	assertEquals(t, "", entry.Title)
+	assertTrue(t, entry.Due.After(time.Now()))
+
+	entry, err = cli.parseAddArguments([]string{"call", "bob", "in", "2h"}, true)
+	assertTrue(t, err == nil)
+	assertEquals(t, "call bob", entry.Title)
+
+	entry, err = cli.parseAddArguments([]string{""}, true)
+	assertTrue(t, err == nil)
+	assertEquals(t, "", entry.Title)
+}
+
+func TestParseAddArguments_blankArguments(t *testing.T) {
+	cli := cli{location: locationBerlin()}
+	entry, err := cli.parseAddArguments([]string{"call", "bob", ""}, false)
+	assertTrue(t, err == nil)
+	assertEquals(t, "call bob", entry.Title)
+}
+
+func TestAdd_titleFromStdinWithDueArgument(t *testing.T) {
+	app := newTestApp(t)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cli := cli{app: app, location: locationBerlin(), output: output{stdout: stdout, stderr: stderr}}
+	reader, writer, err := os.Pipe()
+	assertTrue(t, err == nil)
+	_, _ = writer.WriteString("buy milk\n")
+	_ = writer.Close()
+	stdin := os.Stdin
+	os.Stdin = reader
+	defer func() { os.Stdin = stdin }()
+
+	cli.add([]string{"-", "tomorrow"})
+
+	assertEquals(t, "", stderr.String())
+	entry, _ := app.find("buy milk")
+	assertTrue(t, entry != nil)
+	assertTrue(t, entry.Due.After(time.Now()))
+	missing, _ := app.find("tomorrow")
+	assertTrue(t, missing == nil)
+}
+
+func TestAdd_argumentsWithoutTerminal(t *testing.T) {
+	app := newTestApp(t)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cli := cli{app: app, location: locationBerlin(), output: output{stdout: stdout, stderr: stderr}}
+	reader, writer, err := os.Pipe()
+	assertTrue(t, err == nil)
+	// like run by cron, stdin is no terminal, but left open without anything to read
+	defer writer.Close()
+	stdin := os.Stdin
+	os.Stdin = reader
+	defer func() { os.Stdin = stdin }()
+
+	cli.add([]string{"buy", "milk", "tomorrow"})
+
+	assertEquals(t, "", stderr.String())
+	entry, _ := app.find("buy milk")
+	assertTrue(t, entry != nil)
+	assertEquals(t, "buy milk", entry.Title)
+	assertEquals(t, "", entry.Details)
+	assertTrue(t, entry.Due.After(time.Now()))
+}
+
 func TestFormatRelativeTo_future(t *testing.T) {
 	eventTime := "2023-08-23T12:00:00Z"
 	relativeTime := "2023-08-19T12:00:00Z"
@@ -8,18 +8,43 @@ import (
 )
 
 type config struct {
-	TodoDir         string        `properties:"todoDir,default="`
-	EditorCmd       string        `properties:"editor_command,default="`
-	RemoteBaseUrl   string        `properties:"remote_base_url,default="`
-	Tick            time.Duration `properties:"tick,default=0"`
-	NotificationCmd string        `properties:"notification_command,default="`
-	TrayIcon        string        `properties:"tray_icon,default="`
-	RestBaseHost    string        `properties:"rest_base_host,default="`
-	RestBasePort    string        `properties:"rest_base_port,default="`
+	TodoDir               string        `properties:"todoDir,default="`
+	Storage               string        `properties:"storage,default="`
+	SqliteFile            string        `properties:"sqlite_file,default="`
+	EditorCmd             string        `properties:"editor_command,default="`
+	RemoteBaseUrl         string        `properties:"remote_base_url,default="`
+	RemoteToken           string        `properties:"remote_token,default="`
+	RemoteCaFile          string        `properties:"remote_ca_file,default="`
+	RemoteInsecure        bool          `properties:"remote_insecure_skip_verify,default=false"`
+	SortMode              string        `properties:"sort_mode,default="`
+	DueOnWorkingTime      bool          `properties:"due_on_working_time,default=false"`
+	Tick                  time.Duration `properties:"tick,default=0"`
+	NotificationCmd       string        `properties:"notification_command,default="`
+	WebhookUrls           string        `properties:"webhook_urls,default="`
+	WebhookTimeout        time.Duration `properties:"webhook_timeout,default=0"`
+	WebhookRetries        int           `properties:"webhook_retries,default=2"`
+	EscalationCmd         string        `properties:"escalation_command,default="`
+	EscalationWebhookUrls string        `properties:"escalation_webhook_urls,default="`
+	QuietHours            string        `properties:"quiet_hours,default="`
+	QuietOnDaysOff        bool          `properties:"quiet_on_days_off,default=false"`
+	WorkingDays           string        `properties:"working_days,default="`
+	WorkingHours          string        `properties:"working_hours,default="`
+	ReportSections        string        `properties:"report_sections,default="`
+	ReportWindow          string        `properties:"report_window,default="`
+	TrayIcon              string        `properties:"tray_icon,default="`
+	RestBaseHost          string        `properties:"rest_base_host,default="`
+	RestBasePort          string        `properties:"rest_base_port,default="`
+	RestTokens            string        `properties:"rest_tokens,default="`
+	RestTokenFile         string        `properties:"rest_token_file,default="`
+	RestTlsCert           string        `properties:"rest_tls_cert,default="`
+	RestTlsKey            string        `properties:"rest_tls_key,default="`
 }
 
 func loadConfig() config {
 	config := readTodoDirAndLoadConfig()
+	config = loadStorageConfig(config)
+	config = loadCalendarConfig(config)
+	config = loadReportConfig(config)
 	config = loadCliConfig(config)
 	config = loadServerConfig(config)
 	return config
@@ -34,7 +59,7 @@ func readTodoDirAndLoadConfig() config {
 	if !specified {
 		todoDir = homeDir + "/.todo"
 	}
-	resultConfig := config{}
+	resultConfig := defaultConfig()
 	prop, err := properties.LoadFile(todoDir+"/todo.properties", properties.UTF8)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "No config loaded due to error: %s, using Defaults\n", err)
@@ -44,7 +69,7 @@ func readTodoDirAndLoadConfig() config {
 		if err != nil {
 			_, _ = fmt.Fprintf(os.Stderr, "No config loaded due to error: %s, using Defaults\n", err)
 			todoDir = homeDir + "/.todo"
-			resultConfig = config{}
+			resultConfig = defaultConfig()
 		}
 	}
 	if len(resultConfig.TodoDir) == 0 {
@@ -53,6 +78,47 @@ func readTodoDirAndLoadConfig() config {
 	return resultConfig
 }
 
+// defaultConfig holds the defaults of the properties tags, for settings like webhook_retries whose zero value is
+// a valid choice instead of meaning unset
+func defaultConfig() config {
+	resultConfig := config{}
+	err := properties.NewProperties().Decode(&resultConfig)
+	if err != nil {
+		exitWithError("Error applying the config defaults: ", err)
+	}
+	return resultConfig
+}
+
+func loadStorageConfig(config config) config {
+	if len(config.Storage) == 0 {
+		config.Storage = StorageFs
+	}
+	if len(config.SqliteFile) == 0 {
+		config.SqliteFile = config.TodoDir + "/todo.db"
+	}
+	return config
+}
+
+func loadCalendarConfig(config config) config {
+	if len(config.WorkingDays) == 0 {
+		config.WorkingDays = "mon,tue,wed,thu,fri"
+	}
+	if len(config.WorkingHours) == 0 {
+		config.WorkingHours = "09:00-17:00"
+	}
+	return config
+}
+
+func loadReportConfig(config config) config {
+	if len(config.ReportSections) == 0 {
+		config.ReportSections = "overdue,week,upcoming,resolved"
+	}
+	if len(config.ReportWindow) == 0 {
+		config.ReportWindow = "14d"
+	}
+	return config
+}
+
 func loadCliConfig(config config) config {
 	if len(config.EditorCmd) == 0 {
 		config.EditorCmd = "vim"
@@ -60,6 +126,9 @@ func loadCliConfig(config config) config {
 	if len(config.RemoteBaseUrl) == 0 {
 		config.RemoteBaseUrl = "http://127.0.0.1:8080"
 	}
+	if len(config.SortMode) == 0 {
+		config.SortMode = string(SortModeDue)
+	}
 	return config
 }
 
@@ -70,11 +139,14 @@ func loadServerConfig(config config) config {
 	if len(config.NotificationCmd) == 0 {
 		config.NotificationCmd = ""
 	}
+	if config.WebhookTimeout == 0 {
+		config.WebhookTimeout = 5 * time.Second
+	}
 	if len(config.TrayIcon) == 0 {
 		config.TrayIcon = "todo.png"
 	}
 	if len(config.RestBaseHost) == 0 {
-		config.RestBaseHost = "0.0.0.0"
+		config.RestBaseHost = "127.0.0.1"
 	}
 	if len(config.RestBasePort) == 0 {
 		config.RestBasePort = "8080"
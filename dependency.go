@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+func activeIdsOf(todos []todo) map[uuid.UUID]bool {
+	activeIds := make(map[uuid.UUID]bool, len(todos))
+	for _, entry := range todos {
+		activeIds[entry.Id] = true
+	}
+	return activeIds
+}
+
+// isBlocked reports whether one of the blockers of the todo is still active, resolved and deleted blockers not blocking anymore
+func isBlocked(entry todo, activeIds map[uuid.UUID]bool) bool {
+	for _, blockerId := range entry.BlockedBy {
+		if activeIds[blockerId] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBlockable returns an error if blocking the todo by the blocker would make a todo wait for itself
+func checkBlockable(todos []todo, todoId uuid.UUID, blockerId uuid.UUID) error {
+	if todoId == blockerId {
+		return errors.New("a todo cannot be blocked by itself")
+	}
+	blockedBy := make(map[uuid.UUID][]uuid.UUID, len(todos))
+	for _, entry := range todos {
+		blockedBy[entry.Id] = entry.BlockedBy
+	}
+	visited := make(map[uuid.UUID]bool)
+	pending := []uuid.UUID{blockerId}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if current == todoId {
+			return errors.New(fmt.Sprintf("blocking %s by %s would create a cycle", todoId, blockerId))
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		pending = append(pending, blockedBy[current]...)
+	}
+	return nil
+}
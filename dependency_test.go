@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func TestCheckBlockable_detectsCycles(t *testing.T) {
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+	todos := []todo{{Id: first, BlockedBy: []uuid.UUID{second}}, {Id: second, BlockedBy: []uuid.UUID{third}}, {Id: third}}
+	assertTrue(t, checkBlockable(todos, first, first) != nil)
+	assertTrue(t, checkBlockable(todos, third, first) != nil)
+	assertTrue(t, checkBlockable(todos, second, first) != nil)
+	assertTrue(t, checkBlockable(todos, first, third) == nil)
+}
+
+func TestIsBlocked_onlyByActiveBlockers(t *testing.T) {
+	blocker := uuid.New()
+	entry := todo{Id: uuid.New(), BlockedBy: []uuid.UUID{blocker}}
+	assertTrue(t, isBlocked(entry, map[uuid.UUID]bool{blocker: true}))
+	assertFalse(t, isBlocked(entry, map[uuid.UUID]bool{}))
+}
+
+func TestAppLocal_blockAndReady(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "deploy", Due: time.Now().Add(-time.Hour)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "review", Due: time.Now().Add(time.Hour)}) == nil)
+	deploy, _ := app.find("deploy")
+	review, _ := app.find("review")
+
+	assertTrue(t, app.block(deploy.Id, review.Id) == nil)
+	assertTrue(t, app.block(review.Id, deploy.Id) != nil)
+	deploy, _ = app.find("deploy")
+	assertTrue(t, deploy.Blocked)
+
+	ready, _ := app.findAll(todoFilter{Ready: true})
+	assertTrue(t, len(ready) == 1)
+	assertEquals(t, "review", ready[0].Title)
+	all, _ := app.findAll(todoFilter{})
+	assertTrue(t, len(all) == 2)
+	toBeNotified, _ := app.findToBeNotifiedByDueBefore(time.Now())
+	assertTrue(t, len(toBeNotified) == 0)
+
+	assertTrue(t, app.resolve(review.Id) == nil)
+	deploy, _ = app.find("deploy")
+	assertFalse(t, deploy.Blocked)
+	toBeNotified, _ = app.findToBeNotifiedByDueBefore(time.Now())
+	assertTrue(t, len(toBeNotified) == 1)
+
+	assertTrue(t, app.unblock(deploy.Id, review.Id) == nil)
+	assertTrue(t, app.unblock(deploy.Id, review.Id) != nil)
+	deploy, _ = app.find("deploy")
+	assertTrue(t, len(deploy.BlockedBy) == 0)
+}
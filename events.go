@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type changeType string
+
+const (
+	ChangeTypeCreated  changeType = "created"
+	ChangeTypeUpdated  changeType = "updated"
+	ChangeTypeResolved changeType = "resolved"
+	ChangeTypeDeleted  changeType = "deleted"
+	ChangeTypeNotified changeType = "notified"
+)
+
+// maxEventHistory is the number of events kept for clients resuming the stream
+const maxEventHistory = 1000
+
+// subscriberBufferSize is the number of events a subscriber may fall behind before being dropped
+const subscriberBufferSize = 64
+
+// changeEvent tells about a change of a todo, the ids being '<epoch>-<n>' with the epoch telling the start of the
+// server apart and n counting up from 1 since then
+type changeEvent struct {
+	Id     string    `json:"id"`
+	Type   string    `json:"type"`
+	TodoId uuid.UUID `json:"todoId"`
+	Todo   todoModel `json:"todo"`
+	At     time.Time `json:"at"`
+	number uint64
+}
+
+// eventBus hands the changes made through appLocal to its subscribers, keeping the latest events for
+// subscribers resuming after a given event id
+type eventBus struct {
+	mutex       sync.Mutex
+	epoch       string
+	lastNumber  uint64
+	history     []changeEvent
+	subscribers map[chan changeEvent]bool
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{epoch: strconv.FormatInt(time.Now().UnixNano(), 10), subscribers: make(map[chan changeEvent]bool)}
+}
+
+// publish emits the change of the todo, doing nothing on a nil bus
+func (bus *eventBus) publish(change changeType, entry todo) {
+	if bus == nil {
+		return
+	}
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+	bus.lastNumber++
+	event := changeEvent{Id: fmt.Sprintf("%s-%d", bus.epoch, bus.lastNumber), number: bus.lastNumber, Type: string(change), TodoId: entry.Id, Todo: mapTodo(entry), At: time.Now()}
+	bus.history = append(bus.history, event)
+	if len(bus.history) > maxEventHistory {
+		bus.history = bus.history[len(bus.history)-maxEventHistory:]
+	}
+	for subscriber := range bus.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			// a subscriber too slow to keep up is dropped, it may resume by the id of the last event it got
+			delete(bus.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// subscribe returns the kept events after lastEventId and a channel receiving all events to come, an empty
+// lastEventId replaying nothing. A lastEventId of another epoch, or ahead of the bus, stems from before a restart of
+// the server, replaying all kept events.
+func (bus *eventBus) subscribe(lastEventId string) ([]changeEvent, chan changeEvent) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+	replay := make([]changeEvent, 0)
+	if len(lastEventId) > 0 {
+		after, ok := bus.numberOfInternal(lastEventId)
+		if !ok || after > bus.lastNumber {
+			after = 0
+		}
+		for _, event := range bus.history {
+			if event.number > after {
+				replay = append(replay, event)
+			}
+		}
+	}
+	subscriber := make(chan changeEvent, subscriberBufferSize)
+	bus.subscribers[subscriber] = true
+	return replay, subscriber
+}
+
+// numberOfInternal returns the number of the event id, if it belongs to the epoch of the bus
+func (bus *eventBus) numberOfInternal(eventId string) (uint64, bool) {
+	epoch, number, found := strings.Cut(eventId, "-")
+	if !found || epoch != bus.epoch {
+		return 0, false
+	}
+	parsed, err := strconv.ParseUint(number, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return parsed, true
+}
+
+func (bus *eventBus) unsubscribe(subscriber chan changeEvent) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+	if bus.subscribers[subscriber] {
+		delete(bus.subscribers, subscriber)
+		close(subscriber)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventBus_resumesAfterLastEventId(t *testing.T) {
+	bus := newEventBus()
+	bus.publish(ChangeTypeCreated, todo{Title: "first"})
+	bus.publish(ChangeTypeUpdated, todo{Title: "first"})
+	replay, subscriber := bus.subscribe(bus.epoch + "-1")
+	defer bus.unsubscribe(subscriber)
+	assertTrue(t, len(replay) == 1)
+	assertEquals(t, "updated", replay[0].Type)
+
+	bus.publish(ChangeTypeDeleted, todo{Title: "first"})
+	event := <-subscriber
+	assertEquals(t, bus.epoch+"-3", event.Id)
+	assertEquals(t, "deleted", event.Type)
+
+	replay, ahead := bus.subscribe(bus.epoch + "-42")
+	defer bus.unsubscribe(ahead)
+	assertTrue(t, len(replay) == 3)
+
+	replay, live := bus.subscribe("")
+	defer bus.unsubscribe(live)
+	assertTrue(t, len(replay) == 0)
+}
+
+func TestEventBus_replaysAllAfterRestart(t *testing.T) {
+	before := newEventBus()
+	for i := 0; i < 5; i++ {
+		before.publish(ChangeTypeUpdated, todo{Title: "before restart"})
+	}
+	bus := newEventBus()
+	bus.epoch = before.epoch + "0"
+	bus.publish(ChangeTypeCreated, todo{Title: "first"})
+	bus.publish(ChangeTypeCreated, todo{Title: "second"})
+
+	// the id of the old server is behind the numbers of the new one, still everything is replayed
+	replay, subscriber := bus.subscribe(before.epoch + "-1")
+	defer bus.unsubscribe(subscriber)
+	assertTrue(t, len(replay) == 2)
+	assertEquals(t, "first", replay[0].Todo.Title)
+
+	replay, malformed := bus.subscribe("42")
+	defer bus.unsubscribe(malformed)
+	assertTrue(t, len(replay) == 2)
+}
+
+func TestEventBus_dropsSlowSubscriber(t *testing.T) {
+	bus := newEventBus()
+	_, subscriber := bus.subscribe("")
+	for i := 0; i <= subscriberBufferSize; i++ {
+		bus.publish(ChangeTypeUpdated, todo{Title: "busy"})
+	}
+	received := 0
+	for range subscriber {
+		received++
+	}
+	assertTrue(t, received == subscriberBufferSize)
+	bus.unsubscribe(subscriber)
+}
+
+func TestAppLocal_publishesChanges(t *testing.T) {
+	bus := newEventBus()
+	app := newTestApp(t)
+	app.events = bus
+	assertTrue(t, app.add(todoModel{Title: "title", Due: time.Now()}) == nil)
+	entry, _ := app.find("title")
+	assertTrue(t, app.setPriority(entry.Id, "high") == nil)
+	assertTrue(t, app.markNotified(entry.Id) == nil)
+	assertTrue(t, app.resolve(entry.Id) == nil)
+	assertTrue(t, app.reopen(entry.Id) == nil)
+	assertTrue(t, app.delete(entry.Id) == nil)
+
+	replay, subscriber := bus.subscribe(bus.epoch + "-1")
+	bus.unsubscribe(subscriber)
+	types := []string{"created"}
+	for _, event := range replay {
+		assertTrue(t, event.TodoId == entry.Id)
+		types = append(types, event.Type)
+	}
+	assertEquals(t, "created updated notified resolved updated deleted", strings.Join(types, " "))
+}
+
+func TestEventsHandler_streamsAfterLastEventId(t *testing.T) {
+	bus := newEventBus()
+	bus.publish(ChangeTypeCreated, todo{Title: "first"})
+	bus.publish(ChangeTypeCreated, todo{Title: "second"})
+	rs := newRestServer(nil, bus, reportOptions{})
+	server := httptest.NewServer(http.HandlerFunc(rs.EventsHandler))
+	defer server.Close()
+
+	received := make([]string, 0)
+	client, err := newRestClient(config{RemoteBaseUrl: server.URL})
+	assertTrue(t, err == nil)
+	// the stream ends with an error, as the connection is closed after the second event
+	_ = client.doStream("", bus.epoch+"-1", func(id string, event string, data string) {
+		received = append(received, strings.TrimPrefix(id, bus.epoch+"-")+" "+event)
+		if len(received) == 1 {
+			bus.publish(ChangeTypeResolved, todo{Title: "second"})
+		} else {
+			server.CloseClientConnections()
+		}
+		assertTrue(t, strings.Contains(data, `"title":"second"`))
+	})
+	assertEquals(t, "2 created,3 resolved", strings.Join(received, ","))
+}
+
+func TestSetWriteDeadline_endsStreamToClientNotReading(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+	r := httptest.NewRequest("GET", "/events", nil)
+	r = r.WithContext(withConn(r.Context(), server))
+
+	setWriteDeadline(r, 10*time.Millisecond)
+	_, err := server.Write([]byte("data: nobody reads this\n\n"))
+	assertTrue(t, err != nil)
+
+	setWriteDeadline(r, 0)
+	go func() { _, _ = io.ReadAll(client) }()
+	_, err = server.Write([]byte("data: read again\n\n"))
+	assertTrue(t, err == nil)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type exportFormat string
+
+const (
+	ExportFormatIcs     exportFormat = "ics"
+	ExportFormatTodoTxt exportFormat = "todotxt"
+)
+
+func parseExportFormat(value string) (exportFormat, error) {
+	switch exportFormat(strings.ToLower(value)) {
+	case ExportFormatIcs, ExportFormatTodoTxt:
+		return exportFormat(strings.ToLower(value)), nil
+	}
+	return ExportFormatIcs, errors.New(fmt.Sprintf("export format %s unknown, expecting ics or todotxt.", value))
+}
+
+// collectExportEntries returns the active todos matching the filter, followed by the matching archived ones if asked for
+func collectExportEntries(app app, filter todoFilter, archived bool) []todoModel {
+	entries, _ := app.findAll(filter)
+	entries = sorted(entries, SortModeDue)
+	if archived {
+		archivedEntries, _ := app.findArchived(time.Time{}, time.Time{})
+		archivedEntries, _ = filterByQuery(archivedEntries, filter.Query, time.Now(), filter.location())
+		for _, entry := range archivedEntries {
+			if filter.matches(entry.Tags, entry.Project) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type icsComponent string
+
+const (
+	IcsComponentTodo  icsComponent = "todo"
+	IcsComponentEvent icsComponent = "event"
+)
+
+// icsMaxRepeats bounds the alarm of a repeating notification without escalation, as an alarm cannot repeat forever
+const icsMaxRepeats = 12
+
+const icsTimeLayout = "20060102T150405Z"
+
+func parseIcsComponent(value string) (icsComponent, error) {
+	switch icsComponent(strings.ToLower(value)) {
+	case "", IcsComponentTodo:
+		return IcsComponentTodo, nil
+	case IcsComponentEvent:
+		return IcsComponentEvent, nil
+	}
+	return IcsComponentTodo, errors.New(fmt.Sprintf("calendar component %s unknown, expecting todo or event.", value))
+}
+
+// writeIcs writes the todos as iCalendar, each one as VTODO or VEVENT, resolved todos being completed
+func writeIcs(out io.Writer, entries []todoModel, component icsComponent, now time.Time) error {
+	ics := &icsWriter{builder: &strings.Builder{}}
+	ics.line("BEGIN:VCALENDAR")
+	ics.line("VERSION:2.0")
+	ics.line("PRODID:-//kboeckler//todo//EN")
+	ics.line("CALSCALE:GREGORIAN")
+	ics.line("X-WR-CALNAME:todo")
+	for _, entry := range entries {
+		ics.entry(entry, component, now)
+	}
+	ics.line("END:VCALENDAR")
+	_, err := io.WriteString(out, ics.builder.String())
+	return err
+}
+
+type icsWriter struct {
+	builder *strings.Builder
+}
+
+func (ics *icsWriter) entry(entry todoModel, component icsComponent, now time.Time) {
+	name := "VTODO"
+	if component == IcsComponentEvent {
+		name = "VEVENT"
+	}
+	ics.line("BEGIN:" + name)
+	ics.line("UID:" + entry.Id.String())
+	ics.line("DTSTAMP:" + formatIcsTime(now))
+	ics.line("SEQUENCE:" + fmt.Sprint(entry.Revision))
+	ics.line("SUMMARY:" + escapeIcsText(entry.Title))
+	description := entry.Details
+	if len(entry.Checklist) > 0 {
+		description = strings.TrimSpace(description + "\n\n" + formatChecklist(entry.Checklist))
+	}
+	if len(description) > 0 {
+		ics.line("DESCRIPTION:" + escapeIcsText(description))
+	}
+	if component == IcsComponentEvent {
+		ics.line("DTSTART:" + formatIcsTime(entry.Due))
+		ics.line("STATUS:CONFIRMED")
+	} else {
+		ics.line("DUE:" + formatIcsTime(entry.Due))
+		if entry.ResolvedAt.IsZero() {
+			ics.line("STATUS:NEEDS-ACTION")
+		} else {
+			ics.line("STATUS:COMPLETED")
+			ics.line("COMPLETED:" + formatIcsTime(entry.ResolvedAt))
+		}
+	}
+	if icsPriority, ok := icsPriorities[priority(entry.Priority)]; ok {
+		ics.line("PRIORITY:" + fmt.Sprint(icsPriority))
+	}
+	categories := make([]string, 0, len(entry.Tags)+1)
+	for _, tag := range entry.Tags {
+		categories = append(categories, escapeIcsText(strings.TrimPrefix(tag, "@")))
+	}
+	if len(entry.Project) > 0 {
+		categories = append(categories, escapeIcsText(entry.Project))
+	}
+	if len(categories) > 0 {
+		ics.line("CATEGORIES:" + strings.Join(categories, ","))
+	}
+	if entry.ResolvedAt.IsZero() {
+		ics.alarms(entry, component)
+	}
+	ics.line("END:" + name)
+}
+
+var icsPriorities = map[priority]int{PriorityHigh: 1, PriorityMedium: 5, PriorityLow: 9}
+
+// alarms adds a VALARM for each reminder and one at the due date, repeating like a repeating notification
+func (ics *icsWriter) alarms(entry todoModel, component icsComponent) {
+	if entry.Notification.Type == string(NotificationTypeNone) {
+		return
+	}
+	// alarms of a todo relate to its due date, alarms of an event to its start being the due date
+	related := ";RELATED=END"
+	if component == IcsComponentEvent {
+		related = ""
+	}
+	for _, model := range entry.Notification.Reminders {
+		before, err := parseLeadTime(model.Before)
+		if err != nil {
+			continue
+		}
+		ics.line("BEGIN:VALARM")
+		ics.line("ACTION:DISPLAY")
+		ics.line("DESCRIPTION:" + escapeIcsText(fmt.Sprintf("Due in %s: %s", model.Before, entry.Title)))
+		ics.line("TRIGGER" + related + ":" + formatIcsDuration(-before))
+		ics.line("END:VALARM")
+	}
+	ics.line("BEGIN:VALARM")
+	ics.line("ACTION:DISPLAY")
+	ics.line("DESCRIPTION:" + escapeIcsText(entry.Title))
+	ics.line("TRIGGER" + related + ":PT0S")
+	if entry.Notification.Type == string(NotificationTypeRepeat) {
+		interval, err := time.ParseDuration(entry.Notification.Interval)
+		if err == nil && interval > 0 {
+			repeats := icsMaxRepeats
+			if entry.Notification.EscalateAfter > 0 {
+				repeats = entry.Notification.EscalateAfter
+			}
+			ics.line("REPEAT:" + fmt.Sprint(repeats))
+			ics.line("DURATION:" + formatIcsDuration(interval))
+		}
+	}
+	ics.line("END:VALARM")
+}
+
+// line writes a content line, folded after 75 octets as iCalendar demands
+func (ics *icsWriter) line(content string) {
+	limit := 75
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !isUtf8Start(content[cut]) {
+			cut--
+		}
+		ics.builder.WriteString(content[:cut] + "\r\n ")
+		content = content[cut:]
+		// the space starting a continuation line counts, too
+		limit = 74
+	}
+	ics.builder.WriteString(content + "\r\n")
+}
+
+func isUtf8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func formatIcsTime(t time.Time) string {
+	return t.UTC().Format(icsTimeLayout)
+}
+
+// formatIcsDuration formats a duration like -P1D, PT15M or -PT1H30M
+func formatIcsDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	day := 24 * time.Hour
+	if d > 0 && d%day == 0 {
+		return fmt.Sprintf("%sP%dD", sign, d/day)
+	}
+	formatted := sign + "P"
+	if d >= day {
+		formatted += fmt.Sprintf("%dD", d/day)
+		d %= day
+	}
+	formatted += "T"
+	if d >= time.Hour {
+		formatted += fmt.Sprintf("%dH", d/time.Hour)
+		d %= time.Hour
+	}
+	if d >= time.Minute {
+		formatted += fmt.Sprintf("%dM", d/time.Minute)
+		d %= time.Minute
+	}
+	if d > 0 || strings.HasSuffix(formatted, "T") {
+		formatted += fmt.Sprintf("%dS", d/time.Second)
+	}
+	return formatted
+}
+
+func escapeIcsText(text string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\r\n", "\\n", "\n", "\\n")
+	return replacer.Replace(text)
+}
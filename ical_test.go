@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscapeIcsText(t *testing.T) {
+	assertEquals(t, `a\, b\; c\\d\ne`, escapeIcsText("a, b; c\\d\ne"))
+}
+
+func TestFormatIcsDuration(t *testing.T) {
+	assertEquals(t, "-P1D", formatIcsDuration(-24*time.Hour))
+	assertEquals(t, "PT15M", formatIcsDuration(15*time.Minute))
+	assertEquals(t, "-PT1H30M", formatIcsDuration(-90*time.Minute))
+	assertEquals(t, "P1DT2H", formatIcsDuration(26*time.Hour))
+	assertEquals(t, "PT0S", formatIcsDuration(0))
+}
+
+func TestIcsWriter_foldsLongLines(t *testing.T) {
+	ics := &icsWriter{builder: &strings.Builder{}}
+	ics.line("SUMMARY:" + strings.Repeat("ä", 60))
+	lines := strings.Split(strings.TrimSuffix(ics.builder.String(), "\r\n"), "\r\n")
+	assertTrue(t, len(lines) == 2)
+	assertTrue(t, len(lines[0]) <= 75)
+	assertTrue(t, strings.HasPrefix(lines[1], " "))
+	assertEquals(t, "SUMMARY:"+strings.Repeat("ä", 60), lines[0]+lines[1][1:])
+}
+
+func TestWriteIcs_todoWithAlarms(t *testing.T) {
+	due := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	entry := todoModel{Id: uuid.New(), Title: "Pay rent", Due: due, Revision: 3, Priority: string(PriorityHigh),
+		Tags: []string{"@home"}, Project: "flat",
+		Notification: notificationModel{Type: string(NotificationTypeRepeat), Interval: "30m", EscalateAfter: 4,
+			Reminders: []reminderModel{{Before: "1d"}}}}
+	out := &bytes.Buffer{}
+	assertTrue(t, writeIcs(out, []todoModel{entry}, IcsComponentTodo, due) == nil)
+	ics := out.String()
+	assertTrue(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n"))
+	assertTrue(t, strings.Contains(ics, "BEGIN:VTODO\r\nUID:"+entry.Id.String()+"\r\n"))
+	assertTrue(t, strings.Contains(ics, "DUE:20230501T100000Z\r\n"))
+	assertTrue(t, strings.Contains(ics, "SEQUENCE:3\r\n"))
+	assertTrue(t, strings.Contains(ics, "STATUS:NEEDS-ACTION\r\n"))
+	assertTrue(t, strings.Contains(ics, "PRIORITY:1\r\n"))
+	assertTrue(t, strings.Contains(ics, "CATEGORIES:home,flat\r\n"))
+	assertTrue(t, strings.Contains(ics, "TRIGGER;RELATED=END:-P1D\r\n"))
+	assertTrue(t, strings.Contains(ics, "TRIGGER;RELATED=END:PT0S\r\nREPEAT:4\r\nDURATION:PT30M\r\n"))
+	assertTrue(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+}
+
+func TestWriteIcs_resolvedTodoAndEvent(t *testing.T) {
+	due := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	resolved := todoModel{Id: uuid.New(), Title: "Done", Due: due, ResolvedAt: due.Add(time.Hour),
+		Notification: notificationModel{Type: string(NotificationTypeOnce)}}
+	out := &bytes.Buffer{}
+	assertTrue(t, writeIcs(out, []todoModel{resolved}, IcsComponentTodo, due) == nil)
+	assertTrue(t, strings.Contains(out.String(), "STATUS:COMPLETED\r\nCOMPLETED:20230501T110000Z\r\n"))
+	assertFalse(t, strings.Contains(out.String(), "VALARM"))
+
+	active := todoModel{Id: uuid.New(), Title: "Meeting", Due: due, Notification: notificationModel{Type: string(NotificationTypeOnce)}}
+	out.Reset()
+	assertTrue(t, writeIcs(out, []todoModel{active}, IcsComponentEvent, due) == nil)
+	assertTrue(t, strings.Contains(out.String(), "BEGIN:VEVENT\r\n"))
+	assertTrue(t, strings.Contains(out.String(), "DTSTART:20230501T100000Z\r\n"))
+	assertTrue(t, strings.Contains(out.String(), "TRIGGER:PT0S\r\n"))
+}
+
+func TestCalendarHandler(t *testing.T) {
+	app, server := newTestRestServer(t)
+	assertTrue(t, app.add(todoModel{Title: "first", Due: time.Now(), Tags: []string{"+work"}}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "second", Due: time.Now()}) == nil)
+
+	res, err := http.Get(server.URL + "/calendar.ics?tag=%2Bwork")
+	assertTrue(t, err == nil)
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	assertEquals(t, "text/calendar; charset=utf-8", res.Header.Get("Content-Type"))
+	assertTrue(t, strings.Contains(string(body), "SUMMARY:first\r\n"))
+	assertFalse(t, strings.Contains(string(body), "SUMMARY:second\r\n"))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"strings"
+)
+
+// matchRank tells how well a todo matches a search, lower ranks matching better
+type matchRank int
+
+const (
+	MatchRankShortId matchRank = iota
+	MatchRankId
+	MatchRankExactTitle
+	MatchRankPartialId
+	MatchRankPartialTitle
+	MatchRankNone
+)
+
+func rankMatch(id uuid.UUID, title string, shortId string, searchFor string) matchRank {
+	switch {
+	case len(shortId) > 0 && strings.EqualFold(shortId, searchFor):
+		return MatchRankShortId
+	case strings.EqualFold(id.String(), searchFor):
+		return MatchRankId
+	case strings.EqualFold(title, searchFor):
+		return MatchRankExactTitle
+	case strings.Contains(strings.ToUpper(id.String()), strings.ToUpper(searchFor)):
+		return MatchRankPartialId
+	case strings.Contains(strings.ToUpper(title), strings.ToUpper(searchFor)):
+		return MatchRankPartialTitle
+	}
+	return MatchRankNone
+}
+
+// isUnambiguousMatch tells whether the first of the ranked candidates is the one searched for, being the only one or
+// matching its short id, id or title exactly while the others match less well
+func isUnambiguousMatch(candidates []todoModel, idMap ShortIdMap, searchFor string) bool {
+	if len(candidates) < 2 {
+		return len(candidates) == 1
+	}
+	first := rankMatch(candidates[0].Id, candidates[0].Title, idMap[candidates[0].Id.String()], searchFor)
+	second := rankMatch(candidates[1].Id, candidates[1].Title, idMap[candidates[1].Id.String()], searchFor)
+	return first <= MatchRankExactTitle && first < second
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRankMatch(t *testing.T) {
+	entry := todoModel{Title: "Report"}
+	assertTrue(t, rankMatch(entry.Id, entry.Title, "a", "A") == MatchRankShortId)
+	assertTrue(t, rankMatch(entry.Id, entry.Title, "a", entry.Id.String()) == MatchRankId)
+	assertTrue(t, rankMatch(entry.Id, entry.Title, "a", "report") == MatchRankExactTitle)
+	assertTrue(t, rankMatch(entry.Id, entry.Title, "a", "epo") == MatchRankPartialTitle)
+	assertTrue(t, rankMatch(entry.Id, entry.Title, "a", "summary") == MatchRankNone)
+}
+
+func TestAppLocal_findMatchingRanksExactTitleFirst(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "weekly report", Due: time.Now()}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "report", Due: time.Now()}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "report expenses", Due: time.Now()}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "water plants", Due: time.Now()}) == nil)
+
+	candidates, idMap := app.findMatching("report")
+	assertTrue(t, len(candidates) == 3)
+	assertEquals(t, "report", candidates[0].Title)
+	assertTrue(t, isUnambiguousMatch(candidates, idMap, "report"))
+
+	entry, _ := app.find("report")
+	assertEquals(t, "report", entry.Title)
+
+	candidates, idMap = app.findMatching("rep")
+	assertTrue(t, len(candidates) == 3)
+	assertFalse(t, isUnambiguousMatch(candidates, idMap, "rep"))
+}
+
+func TestCli_chooseRefusesWhenNotInteractive(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cli := cli{location: locationBerlin(), output: output{stdout: stdout, stderr: stderr}}
+	candidates := []todoModel{{Title: "weekly report", Due: time.Now()}, {Title: "report expenses", Due: time.Now()}}
+
+	chosen := cli.choose(candidates, ShortIdMap{}, "rep", strings.NewReader("1\n"), false)
+
+	assertTrue(t, chosen == nil)
+	assertTrue(t, strings.Contains(stderr.String(), "2 entries found matching rep"))
+	assertTrue(t, strings.Contains(stderr.String(), "weekly report"))
+	assertTrue(t, strings.Contains(stderr.String(), "report expenses"))
+}
+
+func TestCli_chooseByNumber(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cli := cli{location: locationBerlin(), output: output{stdout: stdout, stderr: stderr}}
+	candidates := []todoModel{{Title: "weekly report", Due: time.Now()}, {Title: "report expenses", Due: time.Now()}}
+
+	chosen := cli.choose(candidates, ShortIdMap{}, "rep", strings.NewReader("2\n"), true)
+	assertEquals(t, "report expenses", chosen.Title)
+
+	chosen = cli.choose(candidates, ShortIdMap{}, "rep", strings.NewReader("\n"), true)
+	assertTrue(t, chosen == nil)
+	chosen = cli.choose(candidates, ShortIdMap{}, "rep", strings.NewReader("3\n"), true)
+	assertTrue(t, chosen == nil)
+}
+
+func TestSearchHandler_returnsEveryMatch(t *testing.T) {
+	app, server := newTestRestServer(t)
+	assertTrue(t, app.add(todoModel{Title: "weekly report", Due: time.Now()}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "report", Due: time.Now()}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "water plants", Due: time.Now()}) == nil)
+
+	body, _ := json.Marshal(SearchBody{SearchFor: "report"})
+	res, err := http.Post(server.URL+"/search", "application/json", bytes.NewReader(body))
+	assertTrue(t, err == nil)
+	defer res.Body.Close()
+	response := TodosResponse{}
+	assertTrue(t, json.NewDecoder(res.Body).Decode(&response) == nil)
+	assertTrue(t, len(response.Todos) == 2)
+	assertEquals(t, "report", response.Todos[0].Title)
+	assertEquals(t, "weekly report", response.Todos[1].Title)
+}
@@ -0,0 +1,21 @@
+package main
+
+func migrateFsToSqlite(config config, out output) {
+	source := newRepositoryFs(config)
+	target := newRepositorySqlite(config)
+	imported, skipped := 0, 0
+	entries := source.readArchivedEntries()
+	archivedCount := len(entries)
+	entries = append(entries, source.readAllEntries()...)
+	for i, entry := range entries {
+		inserted, err := target.importEntry(entry, i < archivedCount)
+		if err != nil {
+			out.Errorf("Could not import %s %s: %s\n", entry.Id, entry.Title, err)
+		} else if inserted {
+			imported++
+		} else {
+			skipped++
+		}
+	}
+	out.Resultf("Imported %d todos from %s into %s, skipped %d already present\n", imported, config.TodoDir, config.SqliteFile, skipped)
+}
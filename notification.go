@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseNotificationSetting parses a notification setting like 'none', 'once', 'repeat 30m' or 'repeat 30m escalate 3'
+func parseNotificationSetting(value string) (notificationModel, error) {
+	fields := strings.Fields(strings.ToLower(value))
+	if len(fields) == 0 {
+		return notificationModel{}, nil
+	}
+	setting := notificationModel{Type: fields[0]}
+	if fields[0] != string(NotificationTypeRepeat) {
+		if len(fields) > 1 {
+			return setting, fmt.Errorf("notification %s not understood", value)
+		}
+		return setting, nil
+	}
+	if len(fields) > 1 && fields[1] == "every" {
+		fields = fields[1:]
+	}
+	if len(fields) < 2 {
+		return setting, fmt.Errorf("notification %s needs an interval", value)
+	}
+	interval, err := parseLeadTime(fields[1])
+	if err != nil {
+		return setting, err
+	}
+	setting.Interval = formatLeadTime(interval)
+	fields = fields[2:]
+	if len(fields) > 0 && fields[0] == "escalate" {
+		fields = fields[1:]
+		if len(fields) > 0 && fields[0] == "after" {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			return setting, fmt.Errorf("notification %s needs a number of repeats to escalate after", value)
+		}
+		setting.EscalateAfter, err = strconv.Atoi(fields[0])
+		if err != nil || setting.EscalateAfter <= 0 {
+			return setting, fmt.Errorf("notification %s needs a number of repeats to escalate after", value)
+		}
+		fields = fields[1:]
+	}
+	if len(fields) > 0 {
+		return setting, fmt.Errorf("notification %s not understood", value)
+	}
+	return setting, nil
+}
+
+func formatNotificationSetting(setting notificationModel) string {
+	if setting.Type != string(NotificationTypeRepeat) {
+		return setting.Type
+	}
+	formatted := fmt.Sprintf("%s %s", setting.Type, setting.Interval)
+	if setting.EscalateAfter > 0 {
+		formatted += fmt.Sprintf(" escalate %d", setting.EscalateAfter)
+	}
+	return formatted
+}
+
+// ParseNag strips a 'nag:30m' token from the arguments, repeating the notification every 30 minutes,
+// or 'nag:30m,3' escalating after 3 repeats.
+func ParseNag(arguments []string) ([]string, notificationModel) {
+	remaining := make([]string, 0, len(arguments))
+	result := notificationModel{}
+	for _, argument := range arguments {
+		if len(argument) > len("nag:") && strings.EqualFold("nag:", argument[:len("nag:")]) {
+			setting, err := parseNotificationSetting("repeat " + strings.Replace(argument[len("nag:"):], ",", " escalate ", 1))
+			if err == nil {
+				result = setting
+				continue
+			}
+		}
+		remaining = append(remaining, argument)
+	}
+	return remaining, result
+}
+
+// isEscalated reports whether the next notification of a repeating notification is escalated,
+// which is the case once it was repeated as often as configured
+func isEscalated(setting notificationModel) bool {
+	repeats := len(setting.History) - 1
+	return setting.Type == string(NotificationTypeRepeat) && setting.EscalateAfter > 0 && repeats >= setting.EscalateAfter
+}
+
+// applyNotificationSetting overwrites the type, interval and escalation of the notification with the given setting
+func applyNotificationSetting(target *notification, setting notificationModel) error {
+	target.Type = notificationType(setting.Type)
+	target.Interval = 0
+	if len(setting.Interval) > 0 {
+		interval, err := parseLeadTime(setting.Interval)
+		if err != nil {
+			return err
+		}
+		target.Interval = interval
+	}
+	target.EscalateAfter = setting.EscalateAfter
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func TestParseNotificationSetting(t *testing.T) {
+	setting, err := parseNotificationSetting("Once")
+	assertTrue(t, err == nil)
+	assertEquals(t, "once", formatNotificationSetting(setting))
+	setting, err = parseNotificationSetting("repeat every 90m escalate after 3")
+	assertTrue(t, err == nil)
+	assertEquals(t, "repeat 1h30m escalate 3", formatNotificationSetting(setting))
+	_, err = parseNotificationSetting("repeat")
+	assertTrue(t, err != nil)
+	_, err = parseNotificationSetting("repeat 30m escalate")
+	assertTrue(t, err != nil)
+	_, err = parseNotificationSetting("once 30m")
+	assertTrue(t, err != nil)
+}
+
+func TestParseNag(t *testing.T) {
+	arguments, setting := ParseNag([]string{"title", "nag:30m,2", "tomorrow"})
+	assertEquals(t, "title tomorrow", join(arguments))
+	assertEquals(t, "repeat 30m escalate 2", formatNotificationSetting(setting))
+	arguments, setting = ParseNag([]string{"title", "nag:never"})
+	assertEquals(t, "title nag:never", join(arguments))
+	assertEquals(t, "", setting.Type)
+}
+
+func TestNotificationIsDue_repeat(t *testing.T) {
+	due := parseRFC3339("2023-11-18T11:00:00+01:00")
+	n := notification{Type: NotificationTypeRepeat, Interval: 30 * time.Minute}
+	assertFalse(t, n.isDue(due, due.Add(-time.Minute)))
+	assertTrue(t, n.isDue(due, due.Add(time.Minute)))
+	n.History = []time.Time{due.Add(time.Minute)}
+	assertFalse(t, n.isDue(due, due.Add(20*time.Minute)))
+	assertTrue(t, n.isDue(due, due.Add(31*time.Minute)))
+}
+
+func TestIsEscalated(t *testing.T) {
+	now := time.Now()
+	setting := notificationModel{Type: "repeat", Interval: "30m", EscalateAfter: 2, History: []time.Time{now, now}}
+	assertFalse(t, isEscalated(setting))
+	setting.History = append(setting.History, now)
+	assertTrue(t, isEscalated(setting))
+	setting.Type = "once"
+	assertFalse(t, isEscalated(setting))
+}
+
+func TestTodoValidate_movesNotifiedAtIntoHistory(t *testing.T) {
+	notifiedAt := parseRFC3339("2023-11-18T11:00:00+01:00")
+	entry := todo{Title: "title", Id: uuid.New(), Notification: notification{Type: "once", NotifiedAt: notifiedAt}}
+	assertTrue(t, entry.validate() == nil)
+	assertTrue(t, entry.Notification.NotifiedAt.IsZero())
+	assertTrue(t, len(entry.Notification.History) == 1)
+	assertTrue(t, entry.Notification.lastNotifiedAt().Equal(notifiedAt))
+	assertTrue(t, (&todo{Notification: notification{Type: "repeat"}}).validate() != nil)
+}
+
+func TestAppLocal_repeatingNotificationKeepsHistory(t *testing.T) {
+	app := newTestApp(t)
+	due := time.Now().Add(-time.Hour)
+	entry := todoModel{Title: "title", Due: due, Notification: notificationModel{Type: "repeat", Interval: "30m"}}
+	assertTrue(t, app.add(entry) == nil)
+
+	notified, _ := app.findToBeNotifiedByDueBefore(time.Now())
+	assertTrue(t, len(notified) == 1)
+	assertTrue(t, app.markNotified(notified[0].Id) == nil)
+	notified, _ = app.findToBeNotifiedByDueBefore(time.Now())
+	assertTrue(t, len(notified) == 0)
+	notified, _ = app.findToBeNotifiedByDueBefore(time.Now().Add(31 * time.Minute))
+	assertTrue(t, len(notified) == 1)
+	assertTrue(t, len(notified[0].Notification.History) == 1)
+	assertEquals(t, "30m", notified[0].Notification.Interval)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EventTypeDue      = "due"
+	EventTypeReminder = "reminder"
+	EventTypeBatch    = "batch"
+)
+
+const (
+	UrgencyNormal   = "normal"
+	UrgencyCritical = "critical"
+)
+
+type notificationEvent struct {
+	Type    string    `json:"type"`
+	Todo    todoModel `json:"todo"`
+	Text    string    `json:"text"`
+	Urgency string    `json:"urgency"`
+	// Todos holds the todos of a batch, Todo being empty then
+	Todos []todoModel `json:"todos,omitempty"`
+}
+
+type notifier interface {
+	name() string
+	notify(event notificationEvent) error
+}
+
+func newNotifiers(config config) []notifier {
+	return newNotifiersInternal(config.NotificationCmd, config.WebhookUrls, config)
+}
+
+// newEscalationNotifiers builds the notifiers used for escalated notifications, empty when none are configured
+func newEscalationNotifiers(config config) []notifier {
+	return newNotifiersInternal(config.EscalationCmd, config.EscalationWebhookUrls, config)
+}
+
+func newNotifiersInternal(cmd string, webhookUrls string, config config) []notifier {
+	notifiers := make([]notifier, 0)
+	if len(cmd) > 0 {
+		notifiers = append(notifiers, &commandNotifier{cmd: cmd})
+	}
+	for _, url := range strings.Split(webhookUrls, ",") {
+		url = strings.TrimSpace(url)
+		if len(url) > 0 {
+			notifiers = append(notifiers, newWebhookNotifier(url, config.WebhookTimeout, config.WebhookRetries))
+		}
+	}
+	return notifiers
+}
+
+// deliver sends the event through every notifier and reports whether at least one of them succeeded
+func deliver(notifiers []notifier, event notificationEvent) bool {
+	delivered := false
+	for _, n := range notifiers {
+		err := n.notify(event)
+		if err != nil {
+			log.Errorf("Error notifying %s via %s: %s", event.Todo.Title, n.name(), err)
+		} else {
+			delivered = true
+		}
+	}
+	return delivered
+}
+
+type commandNotifier struct {
+	cmd string
+}
+
+func (n *commandNotifier) name() string {
+	return "command " + n.cmd
+}
+
+func (n *commandNotifier) notify(event notificationEvent) error {
+	title := event.Todo.Title
+	if event.Type == EventTypeBatch {
+		title = fmt.Sprintf("%d todos", len(event.Todos))
+	}
+	cmd := exec.Command(n.cmd, title, event.Text, event.Urgency)
+	log.Debugf("Calling notification command: %s", cmd)
+	stdout, err := cmd.Output()
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		debugError := "{}"
+		if ok {
+			debugError = string(exitErr.Stderr)
+		}
+		return fmt.Errorf("%s: Stdout: %s. DebugErr: %s", err, stdout, debugError)
+	}
+	log.Debugf("Result of executing notification command: %s", stdout)
+	return nil
+}
+
+type webhookNotifier struct {
+	url        string
+	client     *http.Client
+	retries    int
+	retryDelay time.Duration
+}
+
+func newWebhookNotifier(url string, timeout time.Duration, retries int) *webhookNotifier {
+	return &webhookNotifier{url: url, client: &http.Client{Timeout: timeout}, retries: retries, retryDelay: time.Second}
+}
+
+func (n *webhookNotifier) name() string {
+	return "webhook " + n.url
+}
+
+func (n *webhookNotifier) notify(event notificationEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		err = n.postInternal(payload)
+		if err == nil || attempt >= n.retries {
+			return err
+		}
+		log.Debugf("Retrying webhook %s after error: %s", n.url, err)
+		time.Sleep(time.Duration(attempt+1) * n.retryDelay)
+	}
+}
+
+func (n *webhookNotifier) postInternal(payload []byte) error {
+	res, err := n.client.Post(n.url, "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return errors.New("http response failed with " + strconv.Itoa(res.StatusCode))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/magiconair/properties"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifier_postsEvent(t *testing.T) {
+	var received notificationEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+		assertEquals(t, "application/json", r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	id := uuid.New()
+	n := newWebhookNotifier(server.URL, time.Second, 0)
+	err := n.notify(notificationEvent{Type: EventTypeDue, Todo: todoModel{Title: "title", Id: id}, Text: "text"})
+
+	assertTrue(t, err == nil)
+	assertEquals(t, "due", received.Type)
+	assertEquals(t, "title", received.Todo.Title)
+	assertEquals(t, id.String(), received.Todo.Id.String())
+	assertEquals(t, "text", received.Text)
+}
+
+func TestWebhookNotifier_retriesFailedRequests(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := newWebhookNotifier(server.URL, time.Second, 2)
+	n.retryDelay = 0
+	err := n.notify(notificationEvent{Type: EventTypeDue})
+
+	assertTrue(t, err == nil)
+	assertTrue(t, calls == 3)
+}
+
+func TestWebhookNotifier_failsAfterRetries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	n := newWebhookNotifier(server.URL, time.Second, 1)
+	n.retryDelay = 0
+	err := n.notify(notificationEvent{Type: EventTypeDue})
+
+	assertTrue(t, err != nil)
+	assertTrue(t, calls == 2)
+}
+
+func TestWebhookNotifier_timesOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	n := newWebhookNotifier(server.URL, 50*time.Millisecond, -1)
+	err := n.notify(notificationEvent{Type: EventTypeDue})
+
+	assertTrue(t, err != nil)
+}
+
+func TestDeliver_succeedsWithOneChannel(t *testing.T) {
+	failing := &fakeNotifier{err: errors.New("failed")}
+	succeeding := &fakeNotifier{}
+	assertTrue(t, deliver([]notifier{failing, succeeding}, notificationEvent{}))
+	assertTrue(t, failing.calls == 1 && succeeding.calls == 1)
+	assertFalse(t, deliver([]notifier{failing}, notificationEvent{}))
+}
+
+type fakeNotifier struct {
+	err    error
+	calls  int
+	events []notificationEvent
+}
+
+func (n *fakeNotifier) name() string {
+	return "fake"
+}
+
+func (n *fakeNotifier) notify(event notificationEvent) error {
+	n.calls++
+	n.events = append(n.events, event)
+	return n.err
+}
+
+func TestDefaultConfig_webhookRetries(t *testing.T) {
+	assertTrue(t, defaultConfig().WebhookRetries == 2)
+	disabled := defaultConfig()
+	assertTrue(t, properties.MustLoadString("webhook_retries=0").Decode(&disabled) == nil)
+	assertTrue(t, disabled.WebhookRetries == 0)
+}
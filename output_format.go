@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type outputFormat string
+
+const (
+	OutputFormatText  outputFormat = "text"
+	OutputFormatPlain outputFormat = "plain"
+	OutputFormatJson  outputFormat = "json"
+	OutputFormatCsv   outputFormat = "csv"
+	OutputFormatTsv   outputFormat = "tsv"
+)
+
+func parseOutputFormat(value string) (outputFormat, error) {
+	switch outputFormat(strings.ToLower(value)) {
+	case OutputFormatText, OutputFormatPlain, OutputFormatJson, OutputFormatCsv, OutputFormatTsv:
+		return outputFormat(strings.ToLower(value)), nil
+	}
+	return OutputFormatText, errors.New(fmt.Sprintf("output format %s unknown, expecting text, plain, json, csv or tsv.", value))
+}
+
+// ParseOutputFormat strips an '--output json' or '--output=json' option from the arguments.
+func ParseOutputFormat(arguments []string) ([]string, outputFormat, error) {
+	remaining := make([]string, 0, len(arguments))
+	format := OutputFormatText
+	for i := 0; i < len(arguments); i++ {
+		argument := arguments[i]
+		value := ""
+		if argument == "--output" || argument == "-output" || argument == "-o" {
+			if i+1 >= len(arguments) {
+				return arguments, format, errors.New("output format missing.")
+			}
+			value = arguments[i+1]
+			i++
+		} else if strings.HasPrefix(argument, "--output=") || strings.HasPrefix(argument, "-output=") {
+			value = argument[strings.Index(argument, "=")+1:]
+		} else {
+			remaining = append(remaining, argument)
+			continue
+		}
+		parsed, err := parseOutputFormat(value)
+		if err != nil {
+			return arguments, format, err
+		}
+		format = parsed
+	}
+	return remaining, format, nil
+}
+
+func (f outputFormat) isMachineReadable() bool {
+	return f == OutputFormatJson || f == OutputFormatCsv || f == OutputFormatTsv
+}
+
+// todoOutput is the todoModel json shape extended by the short id of the todo
+type todoOutput struct {
+	ShortId string `json:"shortId"`
+	todoModel
+}
+
+var todoOutputColumns = []string{"shortId", "id", "title", "due", "priority", "project", "tags", "notification", "resolvedAt", "details"}
+
+func writeEntries(out io.Writer, format outputFormat, entries []todoModel, idMap ShortIdMap) error {
+	outputs := make([]todoOutput, 0, len(entries))
+	for _, entry := range entries {
+		outputs = append(outputs, todoOutput{ShortId: idMap[entry.Id.String()], todoModel: entry})
+	}
+	switch format {
+	case OutputFormatJson:
+		return writeJson(out, outputs)
+	case OutputFormatCsv:
+		return writeSeparated(out, ',', outputs)
+	case OutputFormatTsv:
+		return writeSeparated(out, '\t', outputs)
+	}
+	return errors.New(fmt.Sprintf("output format %s is not machine readable.", format))
+}
+
+func writeEntry(out io.Writer, format outputFormat, entry todoModel, shortId string) error {
+	if format == OutputFormatJson {
+		return writeJson(out, todoOutput{ShortId: shortId, todoModel: entry})
+	}
+	return writeEntries(out, format, []todoModel{entry}, ShortIdMap{entry.Id.String(): shortId})
+}
+
+func writeJson(out io.Writer, value any) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func writeSeparated(out io.Writer, separator rune, outputs []todoOutput) error {
+	writer := csv.NewWriter(out)
+	writer.Comma = separator
+	err := writer.Write(todoOutputColumns)
+	if err != nil {
+		return err
+	}
+	for _, output := range outputs {
+		err = writer.Write([]string{
+			output.ShortId,
+			output.Id.String(),
+			output.Title,
+			formatOutputTime(output.Due),
+			output.Priority,
+			output.Project,
+			strings.Join(output.Tags, " "),
+			output.Notification.Type,
+			formatOutputTime(output.ResolvedAt),
+			output.Details,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatOutputTime(timestamp time.Time) string {
+	if timestamp.IsZero() {
+		return ""
+	}
+	return timestamp.Format(time.RFC3339)
+}
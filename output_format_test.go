@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func TestParseOutputFormat_none(t *testing.T) {
+	arguments, format, err := ParseOutputFormat([]string{"+work"})
+	assertTrue(t, err == nil)
+	assertEquals(t, "+work", join(arguments))
+	assertEquals(t, "text", string(format))
+}
+
+func TestParseOutputFormat_separateValue(t *testing.T) {
+	arguments, format, err := ParseOutputFormat([]string{"--output", "JSON", "+work"})
+	assertTrue(t, err == nil)
+	assertEquals(t, "+work", join(arguments))
+	assertEquals(t, "json", string(format))
+}
+
+func TestParseOutputFormat_assignedValue(t *testing.T) {
+	arguments, format, err := ParseOutputFormat([]string{"title", "--output=tsv"})
+	assertTrue(t, err == nil)
+	assertEquals(t, "title", join(arguments))
+	assertEquals(t, "tsv", string(format))
+}
+
+func TestParseOutputFormat_unknown(t *testing.T) {
+	_, _, err := ParseOutputFormat([]string{"--output", "xml"})
+	assertTrue(t, err != nil)
+	_, _, err = ParseOutputFormat([]string{"--output"})
+	assertTrue(t, err != nil)
+}
+
+func TestWriteEntries_json(t *testing.T) {
+	id := uuid.MustParse(ABC)
+	due, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00Z")
+	buffer := &bytes.Buffer{}
+	err := writeEntries(buffer, OutputFormatJson, []todoModel{{Title: "title", Due: due, Id: id}}, ShortIdMap{ABC: "a"})
+	assertTrue(t, err == nil)
+	assertTrue(t, bytes.Contains(buffer.Bytes(), []byte(`"shortId": "a"`)))
+	assertTrue(t, bytes.Contains(buffer.Bytes(), []byte(`"title": "title"`)))
+	assertTrue(t, bytes.Contains(buffer.Bytes(), []byte(`"due": "2023-11-18T14:00:00Z"`)))
+}
+
+func TestWriteEntries_csv(t *testing.T) {
+	id := uuid.MustParse(ABC)
+	due, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00Z")
+	buffer := &bytes.Buffer{}
+	err := writeEntries(buffer, OutputFormatCsv, []todoModel{{Title: "a, b", Due: due, Id: id, Tags: []string{"x", "@y"}}}, ShortIdMap{ABC: "a"})
+	assertTrue(t, err == nil)
+	assertEquals(t, "shortId,id,title,due,priority,project,tags,notification,resolvedAt,details\n"+
+		"a,"+ABC+",\"a, b\",2023-11-18T14:00:00Z,,,x @y,,,\n", buffer.String())
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fatih/color"
+	"strings"
+)
+
+type priority string
+
+const (
+	PriorityNone   priority = ""
+	PriorityHigh   priority = "high"
+	PriorityMedium priority = "medium"
+	PriorityLow    priority = "low"
+)
+
+type sortMode string
+
+const (
+	SortModeDue      sortMode = "due"
+	SortModePriority sortMode = "priority"
+)
+
+func parsePriority(value string) (priority, error) {
+	switch strings.ToLower(value) {
+	case "":
+		return PriorityNone, nil
+	case "high", "h":
+		return PriorityHigh, nil
+	case "medium", "m":
+		return PriorityMedium, nil
+	case "low", "l":
+		return PriorityLow, nil
+	}
+	return PriorityNone, errors.New(fmt.Sprintf("priority %s unknown.", value))
+}
+
+func parseSortMode(value string) (sortMode, error) {
+	switch strings.ToLower(value) {
+	case "", string(SortModeDue):
+		return SortModeDue, nil
+	case string(SortModePriority):
+		return SortModePriority, nil
+	}
+	return SortModeDue, errors.New(fmt.Sprintf("sort mode %s unknown.", value))
+}
+
+// priorityRank orders priorities from high to low, treating an unset priority like medium.
+func priorityRank(value string) int {
+	switch priority(value) {
+	case PriorityHigh:
+		return 0
+	case PriorityLow:
+		return 2
+	}
+	return 1
+}
+
+// ParsePriority strips a '!high' or 'priority:high' token from the arguments.
+func ParsePriority(arguments []string) ([]string, string) {
+	remaining := make([]string, 0, len(arguments))
+	result := ""
+	for _, argument := range arguments {
+		value := ""
+		if len(argument) > 1 && strings.HasPrefix(argument, "!") {
+			value = argument[1:]
+		} else if len(argument) > len("priority:") && strings.EqualFold("priority:", argument[:len("priority:")]) {
+			value = argument[len("priority:"):]
+		}
+		parsed, err := parsePriority(value)
+		if len(value) > 0 && err == nil {
+			result = string(parsed)
+		} else {
+			remaining = append(remaining, argument)
+		}
+	}
+	return remaining, result
+}
+
+func priorityColor(value string) func(a ...interface{}) string {
+	switch priority(value) {
+	case PriorityHigh:
+		return color.New(color.FgRed).SprintFunc()
+	case PriorityMedium:
+		return color.New(color.FgYellow).SprintFunc()
+	}
+	return color.New(color.FgWhite).SprintFunc()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePriority_none(t *testing.T) {
+	arguments, priority := ParsePriority([]string{"title", "!", "tomorrow"})
+	assertEquals(t, "title ! tomorrow", join(arguments))
+	assertEquals(t, "", priority)
+}
+
+func TestParsePriority_shorthand(t *testing.T) {
+	arguments, priority := ParsePriority([]string{"title", "!h", "tomorrow"})
+	assertEquals(t, "title tomorrow", join(arguments))
+	assertEquals(t, "high", priority)
+}
+
+func TestParsePriority_keyValue(t *testing.T) {
+	arguments, priority := ParsePriority([]string{"title", "priority:LOW"})
+	assertEquals(t, "title", join(arguments))
+	assertEquals(t, "low", priority)
+}
+
+func TestParsePriority_unknownStaysInTitle(t *testing.T) {
+	arguments, priority := ParsePriority([]string{"title", "!important"})
+	assertEquals(t, "title !important", join(arguments))
+	assertEquals(t, "", priority)
+}
+
+func TestSorted_dueThenPriority(t *testing.T) {
+	now := time.Now()
+	items := []todoModel{
+		{Title: "later", Due: now.Add(time.Hour), Priority: "high"},
+		{Title: "low", Due: now, Priority: "low"},
+		{Title: "high", Due: now, Priority: "high"},
+		{Title: "unset", Due: now},
+	}
+	assertEquals(t, "high unset low later", titles(sorted(items, SortModeDue)))
+}
+
+func TestSorted_priorityThenDue(t *testing.T) {
+	now := time.Now()
+	items := []todoModel{
+		{Title: "low", Due: now, Priority: "low"},
+		{Title: "later", Due: now.Add(time.Hour), Priority: "high"},
+		{Title: "unset", Due: now},
+		{Title: "high", Due: now, Priority: "high"},
+	}
+	assertEquals(t, "high later unset low", titles(sorted(items, SortModePriority)))
+}
+
+func titles(items []todoModel) string {
+	res := make([]string, 0, len(items))
+	for _, item := range items {
+		res = append(res, item.Title)
+	}
+	return join(res)
+}
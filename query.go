@@ -0,0 +1,475 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// todoQuery tells whether a todo matches a filter expression like 'due<2026-11-01 and title~deploy and not notified'
+type todoQuery func(entry todoModel, now time.Time) bool
+
+var queryStringFields = map[string]func(entry todoModel) string{
+	"title":   func(entry todoModel) string { return entry.Title },
+	"details": func(entry todoModel) string { return entry.Details },
+	"project": func(entry todoModel) string { return entry.Project },
+	"id":      func(entry todoModel) string { return entry.Id.String() },
+}
+
+var queryTimeFields = map[string]func(entry todoModel) time.Time{
+	"due":     func(entry todoModel) time.Time { return entry.Due },
+	"created": func(entry todoModel) time.Time { return entry.CreatedAt },
+}
+
+var queryBoolFields = map[string]func(entry todoModel, now time.Time) bool{
+	"notified": func(entry todoModel, now time.Time) bool {
+		return len(entry.Notification.History) > 0 || !entry.Notification.NotifiedAt.IsZero()
+	},
+	"blocked":   func(entry todoModel, now time.Time) bool { return entry.Blocked },
+	"recurring": func(entry todoModel, now time.Time) bool { return entry.Recurrence != nil },
+	"overdue":   func(entry todoModel, now time.Time) bool { return entry.Due.Before(now) },
+}
+
+var queryPriorityRanks = map[priority]int{PriorityNone: 0, PriorityLow: 1, PriorityMedium: 2, PriorityHigh: 3}
+
+const queryFieldNames = "title, details, project, id, tag, priority, due, created, notified, blocked, recurring or overdue"
+
+// parseTodoQuery parses a filter expression of comparisons like 'field<op>value', combined by 'and', 'or', 'not' and
+// parentheses, terms next to each other meaning 'and'. The operators are '=', '!=', '<', '<=', '>', '>=', and '~'
+// and '!~' for containing. Values holding spaces are quoted. Times are given like '2006-01-02', '2006-01-02 15:04',
+// 'now', 'today', 'tomorrow', 'yesterday' or relative to now like '3d' or '-2h'. notified, blocked, recurring and
+// overdue stand alone. Days and times without a zone are told in the location.
+func parseTodoQuery(expression string, location *time.Location) (todoQuery, error) {
+	tokens, err := tokenizeQuery(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return func(entry todoModel, now time.Time) bool { return true }, nil
+	}
+	parser := &queryParser{tokens: tokens, location: location}
+	query, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.position < len(parser.tokens) {
+		return nil, errors.New(fmt.Sprintf("unexpected '%s' in query", parser.tokens[parser.position].text))
+	}
+	return query, nil
+}
+
+// filterByQuery keeps the entries matching the filter expression, its days and times told in the location
+func filterByQuery(entries []todoModel, expression string, now time.Time, location *time.Location) ([]todoModel, error) {
+	if len(strings.TrimSpace(expression)) == 0 {
+		return entries, nil
+	}
+	query, err := parseTodoQuery(expression, location)
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]todoModel, 0, len(entries))
+	for _, entry := range entries {
+		if query(entry, now) {
+			matching = append(matching, entry)
+		}
+	}
+	return matching, nil
+}
+
+// formatQueryLocation names the location for a rest server evaluating a query, the local location having no name
+// to send being given by its current offset like '+02:00'
+func formatQueryLocation(location *time.Location) string {
+	if location == nil {
+		return ""
+	}
+	if location != time.Local && location.String() != "Local" {
+		return location.String()
+	}
+	return time.Now().In(location).Format("-07:00")
+}
+
+// parseQueryLocation reads a location named like 'Europe/Berlin' or by its offset like '+02:00', the server's local
+// location if empty
+func parseQueryLocation(value string) (*time.Location, error) {
+	if len(value) == 0 {
+		return time.Local, nil
+	}
+	if offset, err := time.Parse("-07:00", value); err == nil {
+		_, seconds := offset.Zone()
+		return time.FixedZone(value, seconds), nil
+	}
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("location %s unknown, expecting like Europe/Berlin or +02:00", value))
+	}
+	return location, nil
+}
+
+type queryTokenKind int
+
+const (
+	queryTokenWord queryTokenKind = iota
+	queryTokenString
+	queryTokenOperator
+	queryTokenOpen
+	queryTokenClose
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+}
+
+var queryOperators = []string{"!=", "!~", "<=", ">=", "=", "<", ">", "~"}
+
+func tokenizeQuery(expression string) ([]queryToken, error) {
+	tokens := make([]queryToken, 0)
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		current := runes[i]
+		if current == ' ' || current == '\t' || current == '\n' {
+			i++
+		} else if current == '(' {
+			tokens = append(tokens, queryToken{kind: queryTokenOpen, text: "("})
+			i++
+		} else if current == ')' {
+			tokens = append(tokens, queryToken{kind: queryTokenClose, text: ")"})
+			i++
+		} else if current == '"' || current == '\'' {
+			end := i + 1
+			for end < len(runes) && runes[end] != current {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, errors.New("quote not closed in query")
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenString, text: string(runes[i+1 : end])})
+			i = end + 1
+		} else if operator := queryOperatorAt(runes[i:]); len(operator) > 0 {
+			tokens = append(tokens, queryToken{kind: queryTokenOperator, text: operator})
+			i += len(operator)
+		} else {
+			end := i
+			for end < len(runes) && !strings.ContainsRune(" \t\n()\"'=!<>~", runes[end]) {
+				end++
+			}
+			if end == i {
+				return nil, errors.New(fmt.Sprintf("unexpected '%c' in query", current))
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenWord, text: string(runes[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+func queryOperatorAt(runes []rune) string {
+	for _, operator := range queryOperators {
+		if strings.HasPrefix(string(runes), operator) {
+			return operator
+		}
+	}
+	return ""
+}
+
+type queryParser struct {
+	tokens   []queryToken
+	position int
+	location *time.Location
+}
+
+func (p *queryParser) peek() *queryToken {
+	if p.position >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.position]
+}
+
+func (p *queryParser) isKeyword(keyword string) bool {
+	token := p.peek()
+	return token != nil && token.kind == queryTokenWord && strings.EqualFold(token.text, keyword)
+}
+
+func (p *queryParser) parseOr() (todoQuery, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.position++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		either := left
+		left = func(entry todoModel, now time.Time) bool { return either(entry, now) || right(entry, now) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (todoQuery, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.isKeyword("and") {
+			p.position++
+		} else if token := p.peek(); token == nil || token.kind == queryTokenClose || p.isKeyword("or") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		both := left
+		left = func(entry todoModel, now time.Time) bool { return both(entry, now) && right(entry, now) }
+	}
+}
+
+func (p *queryParser) parseUnary() (todoQuery, error) {
+	token := p.peek()
+	if token == nil {
+		return nil, errors.New("query ends early")
+	}
+	if p.isKeyword("not") {
+		p.position++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(entry todoModel, now time.Time) bool { return !inner(entry, now) }, nil
+	}
+	if token.kind == queryTokenOpen {
+		p.position++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.peek(); closing == nil || closing.kind != queryTokenClose {
+			return nil, errors.New("')' missing in query")
+		}
+		p.position++
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *queryParser) parseComparison() (todoQuery, error) {
+	token := p.peek()
+	if token.kind != queryTokenWord {
+		return nil, errors.New(fmt.Sprintf("field expected instead of '%s' in query", token.text))
+	}
+	field := strings.ToLower(token.text)
+	p.position++
+	operator := p.peek()
+	if operator == nil || operator.kind != queryTokenOperator {
+		return newBoolComparison(field, "=", "true")
+	}
+	p.position++
+	value := p.peek()
+	if value == nil || (value.kind != queryTokenWord && value.kind != queryTokenString) {
+		return nil, errors.New(fmt.Sprintf("value missing after %s%s in query", field, operator.text))
+	}
+	p.position++
+	if _, ok := queryStringFields[field]; ok {
+		return newStringComparison(field, operator.text, value.text)
+	}
+	if _, ok := queryTimeFields[field]; ok {
+		return newTimeComparison(field, operator.text, value.text, p.location)
+	}
+	switch field {
+	case "tag":
+		return newTagComparison(operator.text, value.text)
+	case "priority":
+		return newPriorityComparison(operator.text, value.text)
+	}
+	return newBoolComparison(field, operator.text, value.text)
+}
+
+func unsupportedOperator(field string, operator string) error {
+	return errors.New(fmt.Sprintf("operator %s not supported for %s", operator, field))
+}
+
+func newStringComparison(field string, operator string, value string) (todoQuery, error) {
+	get := queryStringFields[field]
+	value = strings.ToLower(value)
+	switch operator {
+	case "=":
+		return func(entry todoModel, now time.Time) bool { return strings.ToLower(get(entry)) == value }, nil
+	case "!=":
+		return func(entry todoModel, now time.Time) bool { return strings.ToLower(get(entry)) != value }, nil
+	case "~":
+		return func(entry todoModel, now time.Time) bool { return strings.Contains(strings.ToLower(get(entry)), value) }, nil
+	case "!~":
+		return func(entry todoModel, now time.Time) bool {
+			return !strings.Contains(strings.ToLower(get(entry)), value)
+		}, nil
+	}
+	return nil, unsupportedOperator(field, operator)
+}
+
+// newTagComparison matches if any tag equals or contains the value, '+tag' being written like tag
+func newTagComparison(operator string, value string) (todoQuery, error) {
+	value = strings.ToLower(strings.TrimPrefix(value, "+"))
+	anyTag := func(entry todoModel, match func(tag string) bool) bool {
+		for _, tag := range entry.Tags {
+			if match(strings.ToLower(tag)) {
+				return true
+			}
+		}
+		return false
+	}
+	equals := func(tag string) bool { return tag == value }
+	contains := func(tag string) bool { return strings.Contains(tag, value) }
+	switch operator {
+	case "=":
+		return func(entry todoModel, now time.Time) bool { return anyTag(entry, equals) }, nil
+	case "!=":
+		return func(entry todoModel, now time.Time) bool { return !anyTag(entry, equals) }, nil
+	case "~":
+		return func(entry todoModel, now time.Time) bool { return anyTag(entry, contains) }, nil
+	case "!~":
+		return func(entry todoModel, now time.Time) bool { return !anyTag(entry, contains) }, nil
+	}
+	return nil, unsupportedOperator("tag", operator)
+}
+
+// newPriorityComparison orders the priorities from none over low and medium to high
+func newPriorityComparison(operator string, value string) (todoQuery, error) {
+	wanted := PriorityNone
+	if !strings.EqualFold(value, "none") {
+		parsed, err := parsePriority(value)
+		if err != nil {
+			return nil, err
+		}
+		wanted = parsed
+	}
+	rank := queryPriorityRanks[wanted]
+	compare, err := compareOrdered(operator, "priority")
+	if err != nil {
+		return nil, err
+	}
+	return func(entry todoModel, now time.Time) bool {
+		return compare(queryPriorityRanks[priority(entry.Priority)] - rank)
+	}, nil
+}
+
+// compareOrdered turns the operator into a check of the difference of the compared and the given value
+func compareOrdered(operator string, field string) (func(difference int) bool, error) {
+	switch operator {
+	case "=":
+		return func(difference int) bool { return difference == 0 }, nil
+	case "!=":
+		return func(difference int) bool { return difference != 0 }, nil
+	case "<":
+		return func(difference int) bool { return difference < 0 }, nil
+	case "<=":
+		return func(difference int) bool { return difference <= 0 }, nil
+	case ">":
+		return func(difference int) bool { return difference > 0 }, nil
+	case ">=":
+		return func(difference int) bool { return difference >= 0 }, nil
+	}
+	return nil, unsupportedOperator(field, operator)
+}
+
+// newTimeComparison compares with the span the value stands for, a whole day for a date, so 'due=2026-11-01'
+// matches all day and 'due>2026-11-01' from the next day on
+func newTimeComparison(field string, operator string, value string, location *time.Location) (todoQuery, error) {
+	get := queryTimeFields[field]
+	span, err := parseQueryTime(value, location)
+	if err != nil {
+		return nil, err
+	}
+	compare, err := compareOrdered(operator, field)
+	if err != nil {
+		return nil, err
+	}
+	return func(entry todoModel, now time.Time) bool {
+		start, end := span(now)
+		timestamp := get(entry)
+		difference := 0
+		if timestamp.Before(start) {
+			difference = -1
+		} else if end.After(start) && !timestamp.Before(end) {
+			difference = 1
+		} else if end.Equal(start) && timestamp.After(start) {
+			difference = 1
+		}
+		return compare(difference)
+	}, nil
+}
+
+var queryTimeLayouts = []struct {
+	layout string
+	span   func(start time.Time) time.Time
+}{
+	{"2006-01-02", func(start time.Time) time.Time { return start.AddDate(0, 0, 1) }},
+	{"2006-01-02 15:04", func(start time.Time) time.Time { return start.Add(time.Minute) }},
+	{"2006-01-02T15:04", func(start time.Time) time.Time { return start.Add(time.Minute) }},
+}
+
+// parseQueryTime returns the span a time value stands for at evaluation, a day being a span until the next day
+// and an exact point in time being an empty span. Days and times without a zone are told in the location.
+func parseQueryTime(value string, location *time.Location) (func(now time.Time) (time.Time, time.Time), error) {
+	lower := strings.ToLower(value)
+	days := map[string]int{"today": 0, "tomorrow": 1, "yesterday": -1}
+	if offset, ok := days[lower]; ok {
+		return func(now time.Time) (time.Time, time.Time) {
+			local := now.In(location)
+			start := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, location)
+			return start, start.AddDate(0, 0, 1)
+		}, nil
+	}
+	if lower == "now" {
+		return func(now time.Time) (time.Time, time.Time) { return now, now }, nil
+	}
+	if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+		return func(now time.Time) (time.Time, time.Time) { return timestamp, timestamp }, nil
+	}
+	for _, candidate := range queryTimeLayouts {
+		timestamp, err := time.ParseInLocation(candidate.layout, value, location)
+		if err != nil {
+			continue
+		}
+		end := candidate.span(timestamp)
+		return func(now time.Time) (time.Time, time.Time) { return timestamp, end }, nil
+	}
+	sign := time.Duration(1)
+	if strings.HasPrefix(lower, "-") {
+		sign = -1
+	}
+	offset, err := parseLeadTime(strings.TrimLeft(lower, "+-"))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("time %s not understood, expecting like 2006-01-02, '2006-01-02 15:04', now, today or 3d", value))
+	}
+	return func(now time.Time) (time.Time, time.Time) {
+		at := now.Add(sign * offset)
+		return at, at
+	}, nil
+}
+
+func newBoolComparison(field string, operator string, value string) (todoQuery, error) {
+	get, ok := queryBoolFields[field]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("field %s unknown, expecting %s", field, queryFieldNames))
+	}
+	var wanted bool
+	switch strings.ToLower(value) {
+	case "true", "yes":
+		wanted = true
+	case "false", "no":
+		wanted = false
+	default:
+		return nil, errors.New(fmt.Sprintf("%s is true or false, not %s", field, value))
+	}
+	switch operator {
+	case "=":
+		return func(entry todoModel, now time.Time) bool { return get(entry, now) == wanted }, nil
+	case "!=":
+		return func(entry todoModel, now time.Time) bool { return get(entry, now) != wanted }, nil
+	}
+	return nil, unsupportedOperator(field, operator)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func queryMatches(t *testing.T, expression string, entry todoModel, now time.Time) bool {
+	query, err := parseTodoQuery(expression, time.Local)
+	if err != nil {
+		t.Fatalf("query %s not parsed: %v", expression, err)
+	}
+	return query(entry, now)
+}
+
+func TestParseTodoQuery_comparesFields(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local)
+	entry := todoModel{Title: "Deploy the fix", Project: "shop", Tags: []string{"work", "@office"},
+		Priority: string(PriorityMedium), Due: time.Date(2026, 10, 20, 9, 0, 0, 0, time.Local)}
+
+	assertTrue(t, queryMatches(t, "title~deploy", entry, now))
+	assertTrue(t, queryMatches(t, `title="deploy the fix"`, entry, now))
+	assertFalse(t, queryMatches(t, "title!~deploy", entry, now))
+	assertTrue(t, queryMatches(t, "project=shop", entry, now))
+	assertTrue(t, queryMatches(t, "tag=work", entry, now))
+	assertTrue(t, queryMatches(t, "tag=+work", entry, now))
+	assertTrue(t, queryMatches(t, "tag=@office", entry, now))
+	assertFalse(t, queryMatches(t, "tag!=@office", entry, now))
+	assertTrue(t, queryMatches(t, "priority>=medium", entry, now))
+	assertFalse(t, queryMatches(t, "priority>medium", entry, now))
+	assertTrue(t, queryMatches(t, "due<2026-11-01", entry, now))
+	assertTrue(t, queryMatches(t, "due=2026-10-20", entry, now))
+	assertFalse(t, queryMatches(t, "due<=tomorrow", entry, now))
+	assertTrue(t, queryMatches(t, "due<5d", entry, now))
+	assertFalse(t, queryMatches(t, "overdue", entry, now))
+	assertTrue(t, queryMatches(t, "not notified", entry, now))
+}
+
+func TestParseTodoQuery_combinesTerms(t *testing.T) {
+	now := time.Now()
+	entry := todoModel{Title: "Deploy", Project: "shop", Due: now.Add(-time.Hour)}
+
+	assertTrue(t, queryMatches(t, "title~deploy and overdue", entry, now))
+	assertTrue(t, queryMatches(t, "title~deploy overdue", entry, now))
+	assertFalse(t, queryMatches(t, "title~deploy and not overdue", entry, now))
+	assertTrue(t, queryMatches(t, "project=home or project=shop", entry, now))
+	assertFalse(t, queryMatches(t, "project=home or project=shop and not overdue", entry, now))
+	assertTrue(t, queryMatches(t, "(project=home or project=shop) AND overdue", entry, now))
+	assertTrue(t, queryMatches(t, "", entry, now))
+}
+
+func TestParseTodoQuery_rejectsInvalidExpressions(t *testing.T) {
+	for _, expression := range []string{"colour=red", "title<", "(title~a", "title~a)", "due<someday", "priority=urgent", `title="open`, "overdue=maybe"} {
+		_, err := parseTodoQuery(expression, time.Local)
+		if err == nil {
+			t.Errorf("query %s parsed, expecting an error", expression)
+		}
+	}
+}
+
+func TestParseTodoQuery_tellsDaysInLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assertTrue(t, err == nil)
+	// already the next day in Berlin, while still the day before in UTC
+	now := time.Date(2026, 10, 17, 23, 30, 0, 0, time.UTC)
+	dues := map[string]time.Time{
+		"due=today":               time.Date(2026, 10, 18, 9, 0, 0, 0, berlin),
+		"due=2026-10-18":          time.Date(2026, 10, 18, 0, 30, 0, 0, berlin),
+		`due>="2026-10-18 00:00"`: time.Date(2026, 10, 18, 0, 30, 0, 0, berlin),
+	}
+
+	for location, expected := range map[*time.Location]bool{berlin: true, time.UTC: false} {
+		for expression, due := range dues {
+			query, err := parseTodoQuery(expression, location)
+			assertTrue(t, err == nil)
+			if query(todoModel{Title: "Deploy", Due: due}, now) != expected {
+				t.Errorf("query %s in %s matching %v, expecting %v", expression, location, !expected, expected)
+			}
+		}
+	}
+}
+
+func TestParseQueryLocation(t *testing.T) {
+	for _, value := range []string{"Europe/Berlin", "+02:00", "-05:30", formatQueryLocation(time.Local)} {
+		location, err := parseQueryLocation(value)
+		assertTrue(t, err == nil)
+		assertEquals(t, value, formatQueryLocation(location))
+	}
+	location, err := parseQueryLocation("")
+	assertTrue(t, err == nil && location == time.Local)
+	_, err = parseQueryLocation("Mars/Olympus")
+	assertTrue(t, err != nil)
+}
+
+func TestParseListArguments_takesQuery(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cli := cli{cfg: config{SortMode: string(SortModeDue)}, output: output{stdout: stdout, stderr: stderr}}
+	filter, _, ok := cli.parseListArguments([]string{"+work", "title~deploy", "and", "not", "notified"})
+	assertTrue(t, ok)
+	assertTrue(t, len(filter.Tags) == 1)
+	assertEquals(t, "title~deploy and not notified", filter.Query)
+
+	_, _, ok = cli.parseListArguments([]string{"colour=red"})
+	assertFalse(t, ok)
+	assertEquals(t, "", stdout.String())
+	assertTrue(t, len(stderr.String()) > 0)
+}
+
+func TestAppLocal_findAllByQuery(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "Deploy shop", Due: time.Now().Add(time.Hour), Priority: string(PriorityHigh)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "Deploy blog", Due: time.Now().Add(time.Hour)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "Water plants", Due: time.Now().Add(-time.Hour)}) == nil)
+
+	entries, _ := app.findAll(todoFilter{Query: "title~deploy and priority=high"})
+	assertTrue(t, len(entries) == 1)
+	assertEquals(t, "Deploy shop", entries[0].Title)
+
+	entries, _ = app.findWhereDueBefore(time.Now(), todoFilter{Query: "title~deploy"})
+	assertTrue(t, len(entries) == 0)
+}
+
+func TestAppRemote_findsByQueryInLocation(t *testing.T) {
+	app, server := newTestRestServer(t)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	// the first hour of the day in Berlin is still the day before in UTC
+	assertTrue(t, app.add(todoModel{Title: "Deploy", Due: time.Date(2026, 10, 18, 0, 30, 0, 0, berlin)}) == nil)
+	client, err := newRestClient(config{RemoteBaseUrl: server.URL})
+	assertTrue(t, err == nil)
+	remote := newAppRemote(client)
+
+	for location, expected := range map[*time.Location]int{berlin: 1, time.UTC: 0} {
+		filter := todoFilter{Query: "due=2026-10-18", Location: location}
+		entries, _ := remote.findAll(filter)
+		assertTrue(t, len(entries) == expected)
+		entries, _ = remote.findWhereDueBefore(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), filter)
+		assertTrue(t, len(entries) == expected)
+		local, _ := app.findAll(filter)
+		assertTrue(t, len(local) == expected)
+	}
+}
+
+func TestSearchHandler_combinesCriteria(t *testing.T) {
+	app, server := newTestRestServer(t)
+	assertTrue(t, app.add(todoModel{Title: "Deploy shop", Due: time.Now().Add(-time.Hour)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "Deploy blog", Due: time.Now().Add(time.Hour)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "Water plants", Due: time.Now().Add(-time.Hour)}) == nil)
+
+	body, _ := json.Marshal(SearchBody{DueBefore: time.Now(), Query: "title~deploy"})
+	res, err := http.Post(server.URL+"/search", "application/json", bytes.NewReader(body))
+	assertTrue(t, err == nil)
+	defer res.Body.Close()
+	assertTrue(t, res.StatusCode == http.StatusOK)
+	response := TodosResponse{}
+	assertTrue(t, json.NewDecoder(res.Body).Decode(&response) == nil)
+	assertTrue(t, len(response.Todos) == 1)
+	assertEquals(t, "Deploy shop", response.Todos[0].Title)
+
+	body, _ = json.Marshal(SearchBody{Query: "colour=red"})
+	invalid, err := http.Post(server.URL+"/search", "application/json", bytes.NewReader(body))
+	assertTrue(t, err == nil)
+	defer invalid.Body.Close()
+	message, _ := io.ReadAll(invalid.Body)
+	assertTrue(t, invalid.StatusCode == http.StatusBadRequest)
+	assertTrue(t, len(message) > 0)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type frequency string
+
+const (
+	FrequencyDaily   frequency = "daily"
+	FrequencyWeekly  frequency = "weekly"
+	FrequencyMonthly frequency = "monthly"
+	FrequencyYearly  frequency = "yearly"
+)
+
+type recurrence struct {
+	Frequency  frequency `yaml:"frequency"`
+	Interval   int       `yaml:"interval,omitempty"`
+	Weekdays   []string  `yaml:"weekdays,omitempty"`
+	Until      time.Time `yaml:"until,omitempty"`
+	Count      int       `yaml:"count,omitempty"`
+	Occurrence int       `yaml:"occurrence,omitempty"`
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+func (r *recurrence) validate() error {
+	switch frequency(strings.ToLower(string(r.Frequency))) {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+		r.Frequency = frequency(strings.ToLower(string(r.Frequency)))
+	default:
+		return errors.New(fmt.Sprintf("recurrence frequency %s unknown.", r.Frequency))
+	}
+	if r.Interval < 0 {
+		return errors.New(fmt.Sprintf("recurrence interval %d must not be negative.", r.Interval))
+	}
+	if r.Count < 0 {
+		return errors.New(fmt.Sprintf("recurrence count %d must not be negative.", r.Count))
+	}
+	if len(r.Weekdays) > 0 && r.Frequency != FrequencyWeekly {
+		return errors.New("recurrence weekdays are only supported for a weekly frequency.")
+	}
+	for i, weekday := range r.Weekdays {
+		if _, ok := weekdayNames[strings.ToLower(weekday)]; !ok {
+			return errors.New(fmt.Sprintf("recurrence weekday %s unknown.", weekday))
+		}
+		r.Weekdays[i] = strings.ToLower(weekday)[:3]
+	}
+	return nil
+}
+
+// next returns the first occurrence after the given due date, or false when the rule is exhausted.
+func (r *recurrence) next(due time.Time) (time.Time, bool) {
+	if r.Count > 0 && r.occurrence() >= r.Count {
+		return time.Time{}, false
+	}
+	var next time.Time
+	switch r.Frequency {
+	case FrequencyDaily:
+		next = due.AddDate(0, 0, r.interval())
+	case FrequencyWeekly:
+		if len(r.Weekdays) == 0 {
+			next = due.AddDate(0, 0, 7*r.interval())
+		} else {
+			next = r.nextWeekday(due)
+		}
+	case FrequencyMonthly:
+		next = addMonthsClamped(due, r.interval())
+	case FrequencyYearly:
+		next = addMonthsClamped(due, 12*r.interval())
+	default:
+		return time.Time{}, false
+	}
+	if !r.Until.IsZero() && dateOf(next).After(dateOf(r.Until.In(next.Location()))) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// occurrences returns up to n upcoming occurrences following the given due date.
+func (r *recurrence) occurrences(due time.Time, n int) []time.Time {
+	res := make([]time.Time, 0, n)
+	upcoming := *r
+	for len(res) < n {
+		next, ok := upcoming.next(due)
+		if !ok {
+			break
+		}
+		res = append(res, next)
+		due = next
+		upcoming.Occurrence = upcoming.occurrence() + 1
+	}
+	return res
+}
+
+func (r *recurrence) interval() int {
+	if r.Interval <= 0 {
+		return 1
+	}
+	return r.Interval
+}
+
+func (r *recurrence) occurrence() int {
+	if r.Occurrence <= 0 {
+		return 1
+	}
+	return r.Occurrence
+}
+
+func (r *recurrence) nextWeekday(due time.Time) time.Time {
+	weekdays := make(map[time.Weekday]bool)
+	for _, weekday := range r.Weekdays {
+		weekdays[weekdayNames[strings.ToLower(weekday)]] = true
+	}
+	anchorWeek := startOfWeek(due)
+	candidate := due
+	for i := 0; i < 7*r.interval()+7; i++ {
+		candidate = candidate.AddDate(0, 0, 1)
+		weeksBetween := int(dateOf(startOfWeek(candidate)).Sub(dateOf(anchorWeek)).Hours()+12) / (7 * 24)
+		if weekdays[candidate.Weekday()] && weeksBetween%r.interval() == 0 {
+			return candidate
+		}
+	}
+	return due.AddDate(0, 0, 7*r.interval())
+}
+
+func (r *recurrence) String() string {
+	unit := map[frequency]string{FrequencyDaily: "day", FrequencyWeekly: "week", FrequencyMonthly: "month", FrequencyYearly: "year"}[r.Frequency]
+	description := "every " + unit
+	if r.interval() > 1 {
+		description = fmt.Sprintf("every %d %ss", r.interval(), unit)
+	}
+	if len(r.Weekdays) > 0 {
+		description += " on " + strings.Join(r.Weekdays, ",")
+	}
+	if !r.Until.IsZero() {
+		description += " until " + r.Until.Format("2006-01-02")
+	}
+	if r.Count > 0 {
+		description += fmt.Sprintf(" (%d of %d)", r.occurrence(), r.Count)
+	}
+	return description
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	target := firstOfMonth.AddDate(0, months, 0)
+	lastDay := target.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(target.Year(), target.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return dateOf(t).AddDate(0, 0, -offset)
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// ParseRecurrence strips a rule like "every 2 weeks on mon,thu until 2024-12-31 5 times" from the arguments.
+func ParseRecurrence(arguments []string, location *time.Location) ([]string, *recurrenceModel) {
+	for i := len(arguments) - 1; i >= 0; i-- {
+		if !strings.EqualFold("EVERY", arguments[i]) {
+			continue
+		}
+		rule, consumed := parseRecurrenceRule(arguments[i+1:], location)
+		if rule == nil {
+			continue
+		}
+		remaining := make([]string, 0, len(arguments)-consumed-1)
+		remaining = append(remaining, arguments[:i]...)
+		remaining = append(remaining, arguments[i+1+consumed:]...)
+		return remaining, rule
+	}
+	return arguments, nil
+}
+
+func parseRecurrenceRule(arguments []string, location *time.Location) (*recurrenceModel, int) {
+	rule := recurrenceModel{}
+	pos := 0
+	if pos < len(arguments) {
+		interval, err := strconv.Atoi(arguments[pos])
+		if err == nil && interval > 0 {
+			rule.Interval = interval
+			pos++
+		}
+	}
+	if pos >= len(arguments) {
+		return nil, 0
+	}
+	switch strings.TrimSuffix(strings.ToLower(arguments[pos]), "s") {
+	case "day":
+		rule.Frequency = string(FrequencyDaily)
+	case "week":
+		rule.Frequency = string(FrequencyWeekly)
+	case "month":
+		rule.Frequency = string(FrequencyMonthly)
+	case "year":
+		rule.Frequency = string(FrequencyYearly)
+	default:
+		weekdays, ok := parseWeekdays(arguments[pos])
+		if !ok {
+			return nil, 0
+		}
+		rule.Frequency = string(FrequencyWeekly)
+		rule.Weekdays = weekdays
+	}
+	pos++
+	for pos < len(arguments) {
+		if strings.EqualFold("ON", arguments[pos]) && pos+1 < len(arguments) && rule.Frequency == string(FrequencyWeekly) {
+			weekdays, ok := parseWeekdays(arguments[pos+1])
+			if !ok {
+				break
+			}
+			rule.Weekdays = weekdays
+			pos += 2
+		} else if strings.EqualFold("UNTIL", arguments[pos]) && pos+1 < len(arguments) {
+			until, err := time.ParseInLocation("2006-01-02", arguments[pos+1], location)
+			if err != nil {
+				break
+			}
+			rule.Until = until
+			pos += 2
+		} else if pos+1 < len(arguments) && strings.EqualFold("TIMES", arguments[pos+1]) {
+			count, err := strconv.Atoi(arguments[pos])
+			if err != nil || count <= 0 {
+				break
+			}
+			rule.Count = count
+			pos += 2
+		} else {
+			break
+		}
+	}
+	return &rule, pos
+}
+
+func parseWeekdays(argument string) ([]string, bool) {
+	weekdays := make([]string, 0)
+	for _, name := range strings.Split(argument, ",") {
+		if _, ok := weekdayNames[strings.ToLower(name)]; !ok {
+			return nil, false
+		}
+		weekdays = append(weekdays, strings.ToLower(name)[:3])
+	}
+	return weekdays, true
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence_none(t *testing.T) {
+	arguments, rule := ParseRecurrence([]string{"title", "tomorrow"}, locationBerlin())
+	assertEquals(t, "title tomorrow", join(arguments))
+	assertTrue(t, rule == nil)
+}
+
+func TestParseRecurrence_everyWeek(t *testing.T) {
+	arguments, rule := ParseRecurrence([]string{"title", "every", "week", "tomorrow"}, locationBerlin())
+	assertEquals(t, "title tomorrow", join(arguments))
+	assertEquals(t, "weekly", rule.Frequency)
+}
+
+func TestParseRecurrence_everyTwoWeeksOnWeekdaysUntilTimes(t *testing.T) {
+	arguments, rule := ParseRecurrence([]string{"title", "every", "2", "weeks", "on", "mon,Thursday", "until", "2023-12-31", "5", "times"}, locationBerlin())
+	assertEquals(t, "title", join(arguments))
+	assertEquals(t, "weekly", rule.Frequency)
+	assertTrue(t, rule.Interval == 2)
+	assertEquals(t, "mon thu", join(rule.Weekdays))
+	assertEquals(t, "2023-12-31T00:00:00+01:00", rule.Until.Format(time.RFC3339))
+	assertTrue(t, rule.Count == 5)
+}
+
+func TestParseRecurrence_everyWeekday(t *testing.T) {
+	arguments, rule := ParseRecurrence([]string{"title", "every", "friday"}, locationBerlin())
+	assertEquals(t, "title", join(arguments))
+	assertEquals(t, "weekly", rule.Frequency)
+	assertEquals(t, "fri", join(rule.Weekdays))
+}
+
+func TestParseRecurrence_everyNothing(t *testing.T) {
+	arguments, rule := ParseRecurrence([]string{"title", "every", "nothing"}, locationBerlin())
+	assertEquals(t, "title every nothing", join(arguments))
+	assertTrue(t, rule == nil)
+}
+
+func TestRecurrenceNext_daily(t *testing.T) {
+	rule := recurrence{Frequency: FrequencyDaily, Interval: 3}
+	next, ok := rule.next(parseRFC3339("2023-11-18T11:00:00+01:00"))
+	assertTrue(t, ok)
+	assertEquals(t, "2023-11-21T11:00:00+01:00", next.Format(time.RFC3339))
+}
+
+func TestRecurrenceNext_weeklyOnWeekdays(t *testing.T) {
+	rule := recurrence{Frequency: FrequencyWeekly, Weekdays: []string{"mon", "thu"}}
+	next, ok := rule.next(parseRFC3339("2023-11-20T11:00:00+01:00"))
+	assertTrue(t, ok)
+	assertEquals(t, "2023-11-23T11:00:00+01:00", next.Format(time.RFC3339))
+	next, ok = rule.next(next)
+	assertTrue(t, ok)
+	assertEquals(t, "2023-11-27T11:00:00+01:00", next.Format(time.RFC3339))
+}
+
+func TestRecurrenceNext_everyOtherWeekOnWeekdays(t *testing.T) {
+	rule := recurrence{Frequency: FrequencyWeekly, Interval: 2, Weekdays: []string{"mon", "thu"}}
+	next, ok := rule.next(parseRFC3339("2023-11-23T11:00:00+01:00"))
+	assertTrue(t, ok)
+	assertEquals(t, "2023-12-04T11:00:00+01:00", next.Format(time.RFC3339))
+}
+
+func TestRecurrenceNext_monthlyClampsToEndOfMonth(t *testing.T) {
+	rule := recurrence{Frequency: FrequencyMonthly}
+	next, ok := rule.next(parseRFC3339("2023-01-31T11:00:00+01:00"))
+	assertTrue(t, ok)
+	assertEquals(t, "2023-02-28T11:00:00+01:00", next.Format(time.RFC3339))
+}
+
+func TestRecurrenceNext_yearly(t *testing.T) {
+	rule := recurrence{Frequency: FrequencyYearly}
+	next, ok := rule.next(parseRFC3339("2024-02-29T11:00:00+01:00"))
+	assertTrue(t, ok)
+	assertEquals(t, "2025-02-28T11:00:00+01:00", next.Format(time.RFC3339))
+}
+
+func TestRecurrenceNext_untilExhausted(t *testing.T) {
+	rule := recurrence{Frequency: FrequencyDaily, Until: parseRFC3339("2023-11-19T00:00:00+01:00")}
+	next, ok := rule.next(parseRFC3339("2023-11-18T11:00:00+01:00"))
+	assertTrue(t, ok)
+	assertEquals(t, "2023-11-19T11:00:00+01:00", next.Format(time.RFC3339))
+	_, ok = rule.next(next)
+	assertFalse(t, ok)
+}
+
+func TestRecurrenceOccurrences_countExhausted(t *testing.T) {
+	rule := recurrence{Frequency: FrequencyDaily, Count: 3, Occurrence: 1}
+	occurrences := rule.occurrences(parseRFC3339("2023-11-18T11:00:00+01:00"), 5)
+	assertTrue(t, len(occurrences) == 2)
+}
+
+func TestRecurrenceValidate_weekdaysNeedWeekly(t *testing.T) {
+	rule := recurrence{Frequency: FrequencyDaily, Weekdays: []string{"mon"}}
+	assertTrue(t, rule.validate() != nil)
+}
+
+func parseRFC3339(value string) time.Time {
+	parsed, _ := time.Parse(time.RFC3339, value)
+	return parsed
+}
+
+func join(values []string) string {
+	return strings.Join(values, " ")
+}
+
+func TestAppLocal_resolvingLateCountsSkippedOccurrences(t *testing.T) {
+	app := newTestApp(t)
+	due := time.Now().Add(-3*24*time.Hour - 12*time.Hour)
+	assertTrue(t, app.add(todoModel{Title: "standup", Due: due, Recurrence: &recurrenceModel{Frequency: "daily", Count: 6}}) == nil)
+
+	entry, _ := app.find("standup")
+	assertTrue(t, app.resolve(entry.Id) == nil)
+	next, _ := app.find("standup")
+	assertTrue(t, next != nil)
+	assertTrue(t, next.Recurrence.Occurrence == 5)
+	assertTrue(t, next.Due.Equal(due.AddDate(0, 0, 4)))
+
+	assertTrue(t, app.resolve(next.Id) == nil)
+	last, _ := app.find("standup")
+	assertTrue(t, last != nil)
+	assertTrue(t, last.Recurrence.Occurrence == 6)
+	assertTrue(t, app.resolve(last.Id) == nil)
+	exhausted, _ := app.find("standup")
+	assertTrue(t, exhausted == nil)
+}
+
+func TestAppLocal_resolvingPastTheCountEndsTheRule(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "standup", Due: time.Now().AddDate(0, 0, -10), Recurrence: &recurrenceModel{Frequency: "daily", Count: 5}}) == nil)
+
+	entry, _ := app.find("standup")
+	assertTrue(t, app.resolve(entry.Id) == nil)
+	next, _ := app.find("standup")
+	assertTrue(t, next == nil)
+}
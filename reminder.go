@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type reminder struct {
+	Before time.Duration `yaml:"before"`
+	SentAt time.Time     `yaml:"sentAt,omitempty"`
+}
+
+// isDue reports whether the reminder is to be sent at now, which is the case from its lead time until the todo falls due
+func (r reminder) isDue(due time.Time, now time.Time) bool {
+	return r.SentAt.IsZero() && !now.Before(due.Add(-r.Before)) && now.Before(due)
+}
+
+// parseLeadTime parses a lead time like '15m', '1h30m', '1d' or '2w'
+func parseLeadTime(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	var parsed time.Duration
+	var err error
+	if strings.HasSuffix(value, "d") || strings.HasSuffix(value, "w") {
+		var amount int
+		amount, err = strconv.Atoi(value[:len(value)-1])
+		parsed = time.Duration(amount) * 24 * time.Hour
+		if strings.HasSuffix(value, "w") {
+			parsed *= 7
+		}
+	} else {
+		parsed, err = time.ParseDuration(value)
+	}
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("lead time %s not understood", value)
+	}
+	return parsed, nil
+}
+
+func formatLeadTime(leadTime time.Duration) string {
+	day := 24 * time.Hour
+	if leadTime%(7*day) == 0 {
+		return fmt.Sprintf("%dw", leadTime/(7*day))
+	}
+	if leadTime%day == 0 {
+		return fmt.Sprintf("%dd", leadTime/day)
+	}
+	formatted := leadTime.String()
+	if strings.HasSuffix(formatted, "m0s") {
+		formatted = formatted[:len(formatted)-2]
+	}
+	if strings.HasSuffix(formatted, "h0m") {
+		formatted = formatted[:len(formatted)-2]
+	}
+	return formatted
+}
+
+// parseReminders turns lead times into reminders, dropping duplicates and keeping the largest lead time first
+func parseReminders(leadTimes []string) ([]reminder, error) {
+	reminders := make([]reminder, 0, len(leadTimes))
+	seen := make(map[time.Duration]bool)
+	for _, leadTime := range leadTimes {
+		parsed, err := parseLeadTime(leadTime)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[parsed] {
+			seen[parsed] = true
+			reminders = append(reminders, reminder{Before: parsed})
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].Before > reminders[j].Before
+	})
+	return reminders, nil
+}
+
+// ParseReminders strips a 'remind:1d,15m' token from the arguments.
+func ParseReminders(arguments []string) ([]string, []string) {
+	remaining := make([]string, 0, len(arguments))
+	var result []string
+	for _, argument := range arguments {
+		if len(argument) > len("remind:") && strings.EqualFold("remind:", argument[:len("remind:")]) {
+			leadTimes := strings.Split(argument[len("remind:"):], ",")
+			_, err := parseReminders(leadTimes)
+			if err == nil {
+				result = append(result, leadTimes...)
+				continue
+			}
+		}
+		remaining = append(remaining, argument)
+	}
+	return remaining, result
+}
+
+func resetReminders(reminders []reminder) []reminder {
+	if reminders == nil {
+		return nil
+	}
+	reset := make([]reminder, 0, len(reminders))
+	for _, r := range reminders {
+		reset = append(reset, reminder{Before: r.Before})
+	}
+	return reset
+}
+
+// dueReminder returns the lead time of the shortest reminder of the todo to be sent at now
+func dueReminder(entry todoModel, now time.Time) (string, bool) {
+	var shortest *reminder
+	for _, model := range entry.Notification.Reminders {
+		before, err := parseLeadTime(model.Before)
+		if err != nil {
+			continue
+		}
+		r := reminder{Before: before, SentAt: model.SentAt}
+		if r.isDue(entry.Due, now) && (shortest == nil || r.Before < shortest.Before) {
+			shortest = &r
+		}
+	}
+	if shortest == nil {
+		return "", false
+	}
+	return formatLeadTime(shortest.Before), true
+}
+
+func reminderModelsOf(leadTimes []string) []reminderModel {
+	if leadTimes == nil {
+		return nil
+	}
+	models := make([]reminderModel, 0, len(leadTimes))
+	for _, leadTime := range leadTimes {
+		models = append(models, reminderModel{Before: leadTime})
+	}
+	return models
+}
+
+func leadTimesOf(models []reminderModel) []string {
+	if models == nil {
+		return nil
+	}
+	leadTimes := make([]string, 0, len(models))
+	for _, model := range models {
+		leadTimes = append(leadTimes, model.Before)
+	}
+	return leadTimes
+}
+
+func formatReminders(models []reminderModel) string {
+	return strings.Join(leadTimesOf(models), ", ")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLeadTime(t *testing.T) {
+	leadTime, err := parseLeadTime("1d")
+	assertTrue(t, err == nil && leadTime == 24*time.Hour)
+	leadTime, err = parseLeadTime("2w")
+	assertTrue(t, err == nil && leadTime == 14*24*time.Hour)
+	leadTime, err = parseLeadTime("1h30m")
+	assertTrue(t, err == nil && leadTime == 90*time.Minute)
+	_, err = parseLeadTime("-5m")
+	assertTrue(t, err != nil)
+	_, err = parseLeadTime("soon")
+	assertTrue(t, err != nil)
+}
+
+func TestFormatLeadTime(t *testing.T) {
+	assertEquals(t, "15m", formatLeadTime(15*time.Minute))
+	assertEquals(t, "2h", formatLeadTime(2*time.Hour))
+	assertEquals(t, "1h30m", formatLeadTime(90*time.Minute))
+	assertEquals(t, "1d", formatLeadTime(24*time.Hour))
+	assertEquals(t, "1w", formatLeadTime(7*24*time.Hour))
+	assertEquals(t, "45s", formatLeadTime(45*time.Second))
+}
+
+func TestParseReminders(t *testing.T) {
+	arguments, leadTimes := ParseReminders([]string{"title", "remind:15m,1d", "remind:often", "tomorrow"})
+	assertEquals(t, "title remind:often tomorrow", join(arguments))
+	assertEquals(t, "15m 1d", join(leadTimes))
+	reminders, err := parseReminders([]string{"15m", "1d", "24h"})
+	assertTrue(t, err == nil)
+	assertTrue(t, len(reminders) == 2)
+	assertTrue(t, reminders[0].Before == 24*time.Hour)
+}
+
+func TestDueReminder_shortestWithinLeadTime(t *testing.T) {
+	due := parseRFC3339("2023-11-18T11:00:00+01:00")
+	entry := todoModel{Due: due, Notification: notificationModel{Reminders: []reminderModel{{Before: "1d"}, {Before: "1h"}, {Before: "15m"}}}}
+	before, ok := dueReminder(entry, due.Add(-30*time.Minute))
+	assertTrue(t, ok)
+	assertEquals(t, "1h", before)
+	_, ok = dueReminder(entry, due.Add(-2*24*time.Hour))
+	assertFalse(t, ok)
+	_, ok = dueReminder(entry, due)
+	assertFalse(t, ok)
+}
+
+func TestAppLocal_remindersFireOnce(t *testing.T) {
+	app := newTestApp(t)
+	due := time.Now().Add(10 * time.Minute)
+	entry := todoModel{Title: "title", Due: due, Notification: notificationModel{Reminders: reminderModelsOf([]string{"1d", "15m", "5m"})}}
+	assertTrue(t, app.add(entry) == nil)
+
+	reminded, _ := app.findToBeRemindedAt(time.Now())
+	assertTrue(t, len(reminded) == 1)
+	before, ok := dueReminder(reminded[0], time.Now())
+	assertTrue(t, ok)
+	assertEquals(t, "15m", before)
+	assertTrue(t, app.markReminded(reminded[0].Id, before) == nil)
+
+	reminded, _ = app.findToBeRemindedAt(time.Now())
+	assertTrue(t, len(reminded) == 0)
+	reminded, _ = app.findToBeRemindedAt(due.Add(-time.Minute))
+	assertTrue(t, len(reminded) == 1)
+
+	assertTrue(t, app.setNewDue(reminded[0].Id, due) == nil)
+	reminded, _ = app.findToBeRemindedAt(time.Now())
+	assertTrue(t, len(reminded) == 1)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+type reportSection string
+
+const (
+	ReportSectionOverdue  reportSection = "overdue"
+	ReportSectionWeek     reportSection = "week"
+	ReportSectionUpcoming reportSection = "upcoming"
+	ReportSectionResolved reportSection = "resolved"
+)
+
+var reportSectionTitles = map[reportSection]string{
+	ReportSectionOverdue:  "Overdue",
+	ReportSectionWeek:     "Due this week",
+	ReportSectionUpcoming: "Upcoming",
+	ReportSectionResolved: "Recently resolved",
+}
+
+type reportFormat string
+
+const (
+	ReportFormatMarkdown reportFormat = "markdown"
+	ReportFormatHtml     reportFormat = "html"
+)
+
+func parseReportFormat(value string) (reportFormat, error) {
+	switch strings.ToLower(value) {
+	case "", "markdown", "md":
+		return ReportFormatMarkdown, nil
+	case "html":
+		return ReportFormatHtml, nil
+	}
+	return ReportFormatMarkdown, errors.New(fmt.Sprintf("report format %s unknown, expecting markdown or html.", value))
+}
+
+// reportOptions tells which sections a report shows, upcoming todos being due within the window and recently
+// resolved ones being resolved within the window
+type reportOptions struct {
+	Sections []reportSection
+	Window   time.Duration
+}
+
+func parseReportOptions(sections string, window string) (reportOptions, error) {
+	options := reportOptions{}
+	for _, value := range strings.Split(sections, ",") {
+		section := reportSection(strings.ToLower(strings.TrimSpace(value)))
+		if len(section) == 0 {
+			continue
+		}
+		if _, ok := reportSectionTitles[section]; !ok {
+			return options, errors.New(fmt.Sprintf("report section %s unknown, expecting overdue, week, upcoming or resolved.", value))
+		}
+		options.Sections = append(options.Sections, section)
+	}
+	if len(options.Sections) == 0 {
+		return options, errors.New("report sections missing")
+	}
+	parsedWindow, err := parseLeadTime(window)
+	if err != nil {
+		return options, errors.New(fmt.Sprintf("report window %s not understood", window))
+	}
+	options.Window = parsedWindow
+	return options, nil
+}
+
+// overrideReportOptions replaces the sections and the window of the options by those given, if given
+func overrideReportOptions(options reportOptions, sections string, window string) (reportOptions, error) {
+	if len(sections) == 0 {
+		sections = joinReportSections(options.Sections)
+	}
+	if len(window) == 0 {
+		window = formatLeadTime(options.Window)
+	}
+	return parseReportOptions(sections, window)
+}
+
+func joinReportSections(sections []reportSection) string {
+	values := make([]string, 0, len(sections))
+	for _, section := range sections {
+		values = append(values, string(section))
+	}
+	return strings.Join(values, ",")
+}
+
+type report struct {
+	GeneratedAt string
+	Window      string
+	Sections    []reportSectionData
+}
+
+type reportSectionData struct {
+	Title string
+	Items []reportItem
+}
+
+type reportItem struct {
+	Title   string
+	Labels  string
+	When    string
+	Details string
+}
+
+// buildReport collects the todos of each section, the week ending with the coming monday. Times are told to the
+// minute, as seconds are of no interest in a report.
+func buildReport(app app, filter todoFilter, options reportOptions, now time.Time, location *time.Location) report {
+	now = now.In(location).Truncate(time.Minute)
+	weekEnd := startOfNextWeek(now)
+	windowEnd := now.Add(options.Window)
+	active, _ := app.findAll(filter)
+	active = sorted(active, SortModeDue)
+	result := report{GeneratedAt: now.Format("Mon, 02 Jan 2006 15:04"), Window: formatLeadTime(options.Window)}
+	for _, section := range options.Sections {
+		data := reportSectionData{Title: reportSectionTitles[section], Items: make([]reportItem, 0)}
+		if section == ReportSectionResolved {
+			resolved, _ := app.findArchived(now.Add(-options.Window), time.Time{})
+			resolved, _ = filterByQuery(resolved, filter.Query, now, location)
+			sort.SliceStable(resolved, func(i, j int) bool {
+				return resolved[i].ResolvedAt.After(resolved[j].ResolvedAt)
+			})
+			for _, entry := range resolved {
+				if filter.matches(entry.Tags, entry.Project) {
+					data.Items = append(data.Items, reportItemOf(entry, "resolved "+entry.ResolvedAt.In(location).Format("on Mon, 02 Jan 2006")))
+				}
+			}
+		} else {
+			for _, entry := range active {
+				due := entry.Due
+				if (section == ReportSectionOverdue && due.Before(now)) ||
+					(section == ReportSectionWeek && !due.Before(now) && due.Before(weekEnd)) ||
+					(section == ReportSectionUpcoming && !due.Before(weekEnd) && due.Before(windowEnd)) {
+					data.Items = append(data.Items, reportItemOf(entry, "due "+formatRelativeTimestamp(due.In(location).Truncate(time.Minute), now)))
+				}
+			}
+		}
+		result.Sections = append(result.Sections, data)
+	}
+	return result
+}
+
+func reportItemOf(entry todoModel, when string) reportItem {
+	labels := formatTags(entry.Tags, entry.Project)
+	if len(entry.Priority) > 0 {
+		labels = strings.TrimSpace("!" + entry.Priority + " " + labels)
+	}
+	details := strings.TrimSpace(entry.Details)
+	if len(entry.Checklist) > 0 {
+		details = strings.TrimSpace(details + "\n\n" + formatChecklist(entry.Checklist))
+	}
+	return reportItem{Title: entry.Title, Labels: labels, When: when, Details: details}
+}
+
+func startOfNextWeek(now time.Time) time.Time {
+	daysUntilMonday := (8 - int(now.Weekday())) % 7
+	if daysUntilMonday == 0 {
+		daysUntilMonday = 7
+	}
+	return time.Date(now.Year(), now.Month(), now.Day()+daysUntilMonday, 0, 0, 0, 0, now.Location())
+}
+
+func writeReport(out io.Writer, data report, format reportFormat) error {
+	if format == ReportFormatHtml {
+		return reportHtmlTemplate.Execute(out, data)
+	}
+	return writeMarkdownReport(out, data)
+}
+
+func writeMarkdownReport(out io.Writer, data report) error {
+	builder := strings.Builder{}
+	builder.WriteString("# Todo report\n\n")
+	builder.WriteString(fmt.Sprintf("Generated %s, looking %s ahead and back\n", data.GeneratedAt, data.Window))
+	for _, section := range data.Sections {
+		builder.WriteString(fmt.Sprintf("\n## %s (%d)\n\n", section.Title, len(section.Items)))
+		if len(section.Items) == 0 {
+			builder.WriteString("_Nothing_\n")
+		}
+		for _, item := range section.Items {
+			builder.WriteString("- **" + escapeMarkdown(item.Title) + "**")
+			if len(item.Labels) > 0 {
+				builder.WriteString(" `" + item.Labels + "`")
+			}
+			builder.WriteString(" " + item.When + "\n")
+			if len(item.Details) > 0 {
+				builder.WriteString("\n")
+				for _, line := range strings.Split(item.Details, "\n") {
+					// indented lines stay part of the list item
+					builder.WriteString(strings.TrimRight("  "+line, " ") + "\n")
+				}
+				builder.WriteString("\n")
+			}
+		}
+	}
+	_, err := io.WriteString(out, builder.String())
+	return err
+}
+
+func escapeMarkdown(text string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", "*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[", "]", "\\]", "<", "\\<")
+	return replacer.Replace(text)
+}
+
+var reportHtmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Todo report</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; padding: 0 1em; color: #222; }
+h2 { border-bottom: 1px solid #ccc; padding-bottom: .2em; }
+li { margin-bottom: .8em; }
+.labels { color: #07a; font-family: monospace; }
+.when { color: #666; }
+.details { white-space: pre-wrap; margin: .3em 0 0; color: #444; }
+.nothing { color: #888; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>Todo report</h1>
+<p>Generated {{.GeneratedAt}}, looking {{.Window}} ahead and back</p>
+{{- range .Sections}}
+<h2>{{.Title}} ({{len .Items}})</h2>
+{{- if .Items}}
+<ul>
+{{- range .Items}}
+<li><strong>{{.Title}}</strong>{{if .Labels}} <span class="labels">{{.Labels}}</span>{{end}} <span class="when">{{.When}}</span>
+{{- if .Details}}
+<div class="details">{{.Details}}</div>
+{{- end}}
+</li>
+{{- end}}
+</ul>
+{{- else}}
+<p class="nothing">Nothing</p>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
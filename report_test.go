@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReportOptions(t *testing.T) {
+	options, err := parseReportOptions("overdue, Resolved", "1w")
+	assertTrue(t, err == nil)
+	assertEquals(t, "overdue,resolved", joinReportSections(options.Sections))
+	assertTrue(t, options.Window == 7*24*time.Hour)
+
+	_, err = parseReportOptions("overdue,later", "1w")
+	assertTrue(t, err != nil)
+	_, err = parseReportOptions("", "1w")
+	assertTrue(t, err != nil)
+	_, err = parseReportOptions("overdue", "soon")
+	assertTrue(t, err != nil)
+
+	options, err = overrideReportOptions(options, "", "3d")
+	assertTrue(t, err == nil)
+	assertEquals(t, "overdue,resolved", joinReportSections(options.Sections))
+	assertTrue(t, options.Window == 3*24*time.Hour)
+}
+
+func TestStartOfNextWeek(t *testing.T) {
+	wednesday := time.Date(2023, 8, 23, 15, 0, 0, 0, time.UTC)
+	assertTrue(t, startOfNextWeek(wednesday).Equal(time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)))
+	sunday := time.Date(2023, 8, 27, 23, 0, 0, 0, time.UTC)
+	assertTrue(t, startOfNextWeek(sunday).Equal(time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)))
+	monday := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	assertTrue(t, startOfNextWeek(monday).Equal(time.Date(2023, 9, 4, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestBuildReport(t *testing.T) {
+	app := newTestApp(t)
+	now := time.Now()
+	weekEnd := startOfNextWeek(now)
+	assertTrue(t, app.add(todoModel{Title: "late", Due: now.Add(-time.Hour), Details: "call first"}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "soon", Due: weekEnd.Add(-time.Minute)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "next", Due: weekEnd.Add(time.Hour)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "far", Due: now.Add(30 * 24 * time.Hour)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "done", Due: now, ResolvedAt: now.Add(-time.Hour)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "long done", Due: now, ResolvedAt: now.Add(-20 * 24 * time.Hour)}) == nil)
+
+	options := reportOptions{Sections: []reportSection{ReportSectionOverdue, ReportSectionWeek, ReportSectionUpcoming, ReportSectionResolved}, Window: 14 * 24 * time.Hour}
+	data := buildReport(app, todoFilter{}, options, now, time.Local)
+	assertTrue(t, len(data.Sections) == 4)
+	assertEquals(t, "late", titlesOf(data.Sections[0]))
+	assertEquals(t, "call first", data.Sections[0].Items[0].Details)
+	assertEquals(t, "soon", titlesOf(data.Sections[1]))
+	assertEquals(t, "next", titlesOf(data.Sections[2]))
+	assertEquals(t, "done", titlesOf(data.Sections[3]))
+	assertEquals(t, "due for 1h0m0s", data.Sections[0].Items[0].When)
+}
+
+func titlesOf(section reportSectionData) string {
+	titles := make([]string, 0, len(section.Items))
+	for _, item := range section.Items {
+		titles = append(titles, item.Title)
+	}
+	return strings.Join(titles, ",")
+}
+
+func TestWriteReport(t *testing.T) {
+	data := report{GeneratedAt: "Mon, 21 Aug 2023 12:00", Window: "2w", Sections: []reportSectionData{
+		{Title: "Overdue", Items: []reportItem{{Title: "Fix <b>bug</b>", Labels: "!high +work", When: "due since yesterday", Details: "first\nsecond"}}},
+		{Title: "Upcoming", Items: []reportItem{}},
+	}}
+	out := &bytes.Buffer{}
+	assertTrue(t, writeReport(out, data, ReportFormatMarkdown) == nil)
+	assertTrue(t, strings.Contains(out.String(), "## Overdue (1)\n\n- **Fix \\<b>bug\\</b>** `!high +work` due since yesterday\n\n  first\n  second\n"))
+	assertTrue(t, strings.Contains(out.String(), "## Upcoming (0)\n\n_Nothing_\n"))
+
+	out.Reset()
+	assertTrue(t, writeReport(out, data, ReportFormatHtml) == nil)
+	assertTrue(t, strings.HasPrefix(out.String(), "<!DOCTYPE html>"))
+	assertTrue(t, strings.Contains(out.String(), "<strong>Fix &lt;b&gt;bug&lt;/b&gt;</strong>"))
+	assertTrue(t, strings.Contains(out.String(), `<div class="details">first`+"\n"+`second</div>`))
+	assertTrue(t, strings.Contains(out.String(), `<p class="nothing">Nothing</p>`))
+}
+
+func TestReportHandler(t *testing.T) {
+	app, server := newTestRestServer(t)
+	assertTrue(t, app.add(todoModel{Title: "late", Due: time.Now().Add(-time.Hour)}) == nil)
+
+	res, err := http.Get(server.URL + "/report.html?sections=overdue")
+	assertTrue(t, err == nil)
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	assertEquals(t, "text/html; charset=utf-8", res.Header.Get("Content-Type"))
+	assertTrue(t, strings.Contains(string(body), "<h2>Overdue (1)</h2>"))
+	assertFalse(t, strings.Contains(string(body), "Upcoming"))
+
+	res, err = http.Get(server.URL + "/report.html?window=soon")
+	assertTrue(t, err == nil)
+	res.Body.Close()
+	assertTrue(t, res.StatusCode == http.StatusBadRequest)
+}
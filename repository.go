@@ -1,6 +1,15 @@
 package main
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
+const (
+	StorageFs     = "fs"
+	StorageSqlite = "sqlite"
+)
 
 type repository interface {
 	readAllEntries() []todo
@@ -9,4 +18,16 @@ type repository interface {
 	updateEntry(todo todo)
 	deleteEntry(todo todo)
 	archiveEntry(todo todo)
+	readArchivedEntries() []todo
+	readArchivedEntryById(id uuid.UUID) (todo, error)
+	unarchiveEntry(todo todo) error
+}
+
+func newRepository(config config) repository {
+	if strings.EqualFold(config.Storage, StorageSqlite) {
+		return newRepositoryMutex(newRepositorySqlite(config))
+	} else if !strings.EqualFold(config.Storage, StorageFs) {
+		log.Fatalf("Storage %s unknown", config.Storage)
+	}
+	return newRepositoryMutex(newRepositoryFs(config))
 }
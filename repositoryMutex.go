@@ -14,38 +14,56 @@ func newRepositoryMutex(innerRepo repository) *repositoryMutex {
 	return &repositoryMutex{mu: sync.Mutex{}, innerRepo: innerRepo}
 }
 
-func (r repositoryMutex) readAllEntries() []todo {
+func (r *repositoryMutex) readAllEntries() []todo {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	return r.innerRepo.readAllEntries()
 }
 
-func (r repositoryMutex) readEntryById(id uuid.UUID) (todo, error) {
+func (r *repositoryMutex) readEntryById(id uuid.UUID) (todo, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	return r.innerRepo.readEntryById(id)
 }
 
-func (r repositoryMutex) insertEntry(todo todo) error {
+func (r *repositoryMutex) insertEntry(todo todo) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	return r.innerRepo.insertEntry(todo)
 }
 
-func (r repositoryMutex) updateEntry(todo todo) {
+func (r *repositoryMutex) updateEntry(todo todo) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.innerRepo.updateEntry(todo)
 }
 
-func (r repositoryMutex) deleteEntry(todo todo) {
+func (r *repositoryMutex) deleteEntry(todo todo) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.innerRepo.deleteEntry(todo)
 }
 
-func (r repositoryMutex) archiveEntry(todo todo) {
+func (r *repositoryMutex) archiveEntry(todo todo) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.innerRepo.archiveEntry(todo)
 }
+
+func (r *repositoryMutex) readArchivedEntries() []todo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.innerRepo.readArchivedEntries()
+}
+
+func (r *repositoryMutex) readArchivedEntryById(id uuid.UUID) (todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.innerRepo.readArchivedEntryById(id)
+}
+
+func (r *repositoryMutex) unarchiveEntry(todo todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.innerRepo.unarchiveEntry(todo)
+}
@@ -28,6 +28,15 @@ func (repo *repositoryFs) readAllEntries() []todo {
 	return todos
 }
 
+func (repo *repositoryFs) readArchivedEntries() []todo {
+	entries := repo.scanEntriesInDirInternal(repo.archiveDirInternal())
+	todos := make([]todo, len(entries))
+	for i := 0; i < len(entries); i++ {
+		todos[i] = repo.readEntryFromFileInternal(entries[i])
+	}
+	return todos
+}
+
 func (repo *repositoryFs) readEntryById(id uuid.UUID) (todo, error) {
 	otherIdAsString := id.String()
 	entries := repo.scanEntriesInternal()
@@ -40,6 +49,18 @@ func (repo *repositoryFs) readEntryById(id uuid.UUID) (todo, error) {
 	return todo{}, errors.New("no todo present with id " + otherIdAsString)
 }
 
+func (repo *repositoryFs) readArchivedEntryById(id uuid.UUID) (todo, error) {
+	otherIdAsString := id.String()
+	entries := repo.scanEntriesInDirInternal(repo.archiveDirInternal())
+	for _, entry := range entries {
+		todo := repo.readEntryFromFileInternal(entry)
+		if strings.EqualFold(todo.Id.String(), otherIdAsString) {
+			return todo, nil
+		}
+	}
+	return todo{}, errors.New("no archived todo present with id " + otherIdAsString)
+}
+
 func (repo *repositoryFs) insertEntry(todo todo) error {
 	todoDirExists, _ := repo.existsDir(repo.cfg.TodoDir)
 	if !todoDirExists {
@@ -57,6 +78,13 @@ func (repo *repositoryFs) insertEntry(todo todo) error {
 }
 
 func (repo *repositoryFs) updateEntry(todo todo) {
+	renamedPath := filepath.Join(filepath.Dir(todo.filepath), todo.Title+".yml")
+	if renamedPath != todo.filepath {
+		if _, err := os.Stat(renamedPath); os.IsNotExist(err) {
+			repo.deleteEntryInternal(todo)
+			todo.filepath = renamedPath
+		}
+	}
 	repo.writeEntryInternal(todo)
 }
 
@@ -67,6 +95,22 @@ func (repo *repositoryFs) archiveEntry(todo todo) {
 	repo.moveEntryIntoArchiveInternal(todo)
 }
 
+func (repo *repositoryFs) unarchiveEntry(todo todo) error {
+	filePath := filepath.Join(repo.cfg.TodoDir, todo.Title+".yml")
+	_, err := os.Stat(filePath)
+	if err == nil {
+		return errors.New("an active todo with the same title already exists")
+	}
+	archivedPath := todo.filepath
+	todo.filepath = filePath
+	repo.writeEntryInternal(todo)
+	err = os.Remove(archivedPath)
+	if err != nil {
+		log.Fatalf("Failed to delete archived entry: %s\n", err)
+	}
+	return nil
+}
+
 func (repo *repositoryFs) writeEntryInternal(todo todo) {
 	fileContent, err := yaml.Marshal(&todo)
 	if err != nil {
@@ -85,29 +129,41 @@ func (repo *repositoryFs) deleteEntryInternal(todo todo) {
 	}
 }
 
+func (repo *repositoryFs) archiveDirInternal() string {
+	return filepath.Join(repo.cfg.TodoDir, "archive")
+}
+
 func (repo *repositoryFs) moveEntryIntoArchiveInternal(todo todo) {
-	archiveDir := filepath.Join(repo.cfg.TodoDir, "archive")
+	archiveDir := repo.archiveDirInternal()
 	archiveDirExists, _ := repo.existsDir(archiveDir)
 	if !archiveDirExists {
 		repo.createDir(archiveDir)
 	}
-	err := os.Rename(todo.filepath, filepath.Join(archiveDir, filepath.Base(todo.filepath)))
+	archivePath := filepath.Join(archiveDir, filepath.Base(todo.filepath))
+	if _, err := os.Stat(archivePath); err == nil {
+		archivePath = filepath.Join(archiveDir, strings.TrimSuffix(filepath.Base(todo.filepath), ".yml")+"_"+todo.Id.String()+".yml")
+	}
+	err := os.Rename(todo.filepath, archivePath)
 	if err != nil {
 		log.Fatalf("Failed to move entry: %s\n", err)
 	}
 }
 
 func (repo *repositoryFs) scanEntriesInternal() []string {
+	return repo.scanEntriesInDirInternal(repo.cfg.TodoDir)
+}
+
+func (repo *repositoryFs) scanEntriesInDirInternal(dir string) []string {
 	entries := make([]string, 0)
-	todoDirExists, err := repo.existsDir(repo.cfg.TodoDir)
-	if !todoDirExists {
+	dirExists, err := repo.existsDir(dir)
+	if !dirExists {
 		return entries
 	}
-	files, err := os.ReadDir(repo.cfg.TodoDir)
+	files, err := os.ReadDir(dir)
 	if err == nil {
 		for _, file := range files {
-			if !file.IsDir() && !strings.EqualFold("todo.properties", file.Name()) {
-				entries = append(entries, filepath.Join(repo.cfg.TodoDir, file.Name()))
+			if !file.IsDir() && strings.HasSuffix(file.Name(), ".yml") {
+				entries = append(entries, filepath.Join(dir, file.Name()))
 			}
 		}
 	}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRepositoryFs_readAndUnarchiveArchived(t *testing.T) {
+	repo := newRepositoryFs(config{TodoDir: t.TempDir()})
+	testRepositoryReadAndUnarchiveArchived(t, repo)
+}
+
+func TestRepositoryMutex_readAndUnarchiveArchived(t *testing.T) {
+	repo := newRepositoryMutex(newRepositoryFs(config{TodoDir: t.TempDir()}))
+	testRepositoryReadAndUnarchiveArchived(t, repo)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	_ "modernc.org/sqlite"
+	"os"
+	"path/filepath"
+)
+
+type repositorySqlite struct {
+	cfg config
+	db  *sql.DB
+}
+
+func newRepositorySqlite(config config) *repositorySqlite {
+	repo := &repositorySqlite{cfg: config}
+	repo.openInternal()
+	return repo
+}
+
+func (repo *repositorySqlite) readAllEntries() []todo {
+	return repo.queryEntriesInternal("SELECT data FROM todos WHERE archived = 0 ORDER BY due")
+}
+
+func (repo *repositorySqlite) readEntryById(id uuid.UUID) (todo, error) {
+	entries := repo.queryEntriesInternal("SELECT data FROM todos WHERE archived = 0 AND id = ?", id.String())
+	if len(entries) == 0 {
+		return todo{}, errors.New("no todo present with id " + id.String())
+	}
+	return entries[0], nil
+}
+
+func (repo *repositorySqlite) insertEntry(todo todo) error {
+	var count int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM todos WHERE archived = 0 AND title = ?", todo.Title).Scan(&count)
+	if err != nil {
+		log.Fatalf("Failed to query entries: %s\n", err)
+	}
+	if count > 0 {
+		return errors.New("entry already exists")
+	}
+	return repo.insertEntryInternal(todo, false)
+}
+
+func (repo *repositorySqlite) updateEntry(todo todo) {
+	_, err := repo.db.Exec("UPDATE todos SET title = ?, due = ?, data = ? WHERE id = ?", todo.Title, todo.Due.UTC().Unix(), repo.marshalInternal(todo), todo.Id.String())
+	if err != nil {
+		log.Fatalf("Failed to write entry: %s\n", err)
+	}
+}
+
+func (repo *repositorySqlite) deleteEntry(todo todo) {
+	_, err := repo.db.Exec("DELETE FROM todos WHERE id = ?", todo.Id.String())
+	if err != nil {
+		log.Fatalf("Failed to delete entry: %s\n", err)
+	}
+}
+
+func (repo *repositorySqlite) archiveEntry(todo todo) {
+	_, err := repo.db.Exec("UPDATE todos SET archived = 1 WHERE id = ?", todo.Id.String())
+	if err != nil {
+		log.Fatalf("Failed to move entry: %s\n", err)
+	}
+}
+
+func (repo *repositorySqlite) readArchivedEntries() []todo {
+	return repo.queryEntriesInternal("SELECT data FROM todos WHERE archived = 1 ORDER BY due")
+}
+
+func (repo *repositorySqlite) readArchivedEntryById(id uuid.UUID) (todo, error) {
+	entries := repo.queryEntriesInternal("SELECT data FROM todos WHERE archived = 1 AND id = ?", id.String())
+	if len(entries) == 0 {
+		return todo{}, errors.New("no archived todo present with id " + id.String())
+	}
+	return entries[0], nil
+}
+
+func (repo *repositorySqlite) unarchiveEntry(todo todo) error {
+	var count int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM todos WHERE archived = 0 AND title = ?", todo.Title).Scan(&count)
+	if err != nil {
+		log.Fatalf("Failed to query entries: %s\n", err)
+	}
+	if count > 0 {
+		return errors.New("an active todo with the same title already exists")
+	}
+	_, err = repo.db.Exec("UPDATE todos SET archived = 0, title = ?, due = ?, data = ? WHERE id = ?", todo.Title, todo.Due.UTC().Unix(), repo.marshalInternal(todo), todo.Id.String())
+	if err != nil {
+		log.Fatalf("Failed to write entry: %s\n", err)
+	}
+	return nil
+}
+
+// importEntry inserts an entry with its id kept as is, skipping ids that are already present.
+func (repo *repositorySqlite) importEntry(todo todo, archived bool) (bool, error) {
+	var count int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM todos WHERE id = ?", todo.Id.String()).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	return true, repo.insertEntryInternal(todo, archived)
+}
+
+func (repo *repositorySqlite) insertEntryInternal(todo todo, archived bool) error {
+	_, err := repo.db.Exec("INSERT INTO todos (id, title, due, archived, data) VALUES (?, ?, ?, ?, ?)", todo.Id.String(), todo.Title, todo.Due.UTC().Unix(), archived, repo.marshalInternal(todo))
+	return err
+}
+
+func (repo *repositorySqlite) queryEntriesInternal(query string, args ...any) []todo {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		log.Fatalf("Failed to query entries: %s\n", err)
+	}
+	defer rows.Close()
+	todos := make([]todo, 0)
+	for rows.Next() {
+		var data []byte
+		err = rows.Scan(&data)
+		if err != nil {
+			log.Fatalf("Failed to read entry: %s\n", err)
+		}
+		var entry todo
+		err = yaml.Unmarshal(data, &entry)
+		if err != nil {
+			log.Fatalf("Failed to parse todo from database: %s", err)
+		}
+		err = entry.validate()
+		if err != nil {
+			log.Fatalf("Failed to validate todo %s from database: %s", entry.Id, err)
+		}
+		todos = append(todos, entry)
+	}
+	return todos
+}
+
+func (repo *repositorySqlite) marshalInternal(todo todo) []byte {
+	data, err := yaml.Marshal(&todo)
+	if err != nil {
+		log.Fatalf("Failed to write entry: %s\n", err)
+	}
+	return data
+}
+
+func (repo *repositorySqlite) openInternal() {
+	err := os.MkdirAll(filepath.Dir(repo.cfg.SqliteFile), os.FileMode(0777))
+	if err != nil {
+		log.Fatalf("Error writing %s directory: %s", filepath.Dir(repo.cfg.SqliteFile), err)
+	}
+	var db *sql.DB
+	db, err = sql.Open("sqlite", repo.cfg.SqliteFile+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		log.Fatalf("Failed to open database %s: %s\n", repo.cfg.SqliteFile, err)
+	}
+	_, err = db.Exec(`
+CREATE TABLE IF NOT EXISTS todos (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	due INTEGER NOT NULL,
+	archived INTEGER NOT NULL DEFAULT 0,
+	data BLOB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS todos_due ON todos (archived, due);
+CREATE INDEX IF NOT EXISTS todos_title ON todos (archived, title);
+`)
+	if err != nil {
+		log.Fatalf("Failed to create schema in database %s: %s\n", repo.cfg.SqliteFile, err)
+	}
+	repo.db = db
+}
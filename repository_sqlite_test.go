@@ -0,0 +1,78 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRepositorySqlite_insertReadArchive(t *testing.T) {
+	repo := newRepositorySqlite(config{SqliteFile: filepath.Join(t.TempDir(), "todo.db")})
+	later := todo{Title: "later", Id: uuid.New(), Due: time.Now().Add(time.Hour)}
+	sooner := todo{Title: "sooner", Id: uuid.New(), Due: time.Now()}
+	assertTrue(t, repo.insertEntry(later) == nil)
+	assertTrue(t, repo.insertEntry(sooner) == nil)
+	assertTrue(t, repo.insertEntry(todo{Title: "later", Id: uuid.New()}) != nil)
+
+	entries := repo.readAllEntries()
+	assertTrue(t, len(entries) == 2)
+	assertEquals(t, "sooner", entries[0].Title)
+
+	repo.archiveEntry(sooner)
+	_, err := repo.readEntryById(sooner.Id)
+	assertTrue(t, err != nil)
+	entry, err := repo.readEntryById(later.Id)
+	assertTrue(t, err == nil)
+	assertEquals(t, "later", entry.Title)
+}
+
+func TestRepositorySqlite_importEntrySkipsPresentIds(t *testing.T) {
+	repo := newRepositorySqlite(config{SqliteFile: filepath.Join(t.TempDir(), "todo.db")})
+	entry := todo{Title: "title", Id: uuid.New()}
+	inserted, err := repo.importEntry(entry, true)
+	assertTrue(t, inserted && err == nil)
+	inserted, err = repo.importEntry(entry, false)
+	assertTrue(t, !inserted && err == nil)
+	assertTrue(t, len(repo.readAllEntries()) == 0)
+}
+
+func TestRepositorySqlite_readAndUnarchiveArchived(t *testing.T) {
+	repo := newRepositorySqlite(config{SqliteFile: filepath.Join(t.TempDir(), "todo.db")})
+	testRepositoryReadAndUnarchiveArchived(t, repo)
+}
+
+// testRepositoryReadAndUnarchiveArchived runs the archive round trip against any repository
+func testRepositoryReadAndUnarchiveArchived(t *testing.T, repo repository) {
+	resolved := todo{Title: "resolved", Id: uuid.New(), Due: time.Now(), ResolvedAt: time.Now()}
+	active := todo{Title: "active", Id: uuid.New(), Due: time.Now()}
+	assertTrue(t, repo.insertEntry(resolved) == nil)
+	assertTrue(t, repo.insertEntry(active) == nil)
+	inserted, err := repo.readEntryById(resolved.Id)
+	assertTrue(t, err == nil)
+	repo.archiveEntry(inserted)
+
+	archived := repo.readArchivedEntries()
+	assertTrue(t, len(archived) == 1)
+	assertEquals(t, "resolved", archived[0].Title)
+	assertTrue(t, len(repo.readAllEntries()) == 1)
+	_, err = repo.readArchivedEntryById(active.Id)
+	assertTrue(t, err != nil)
+
+	entry, err := repo.readArchivedEntryById(resolved.Id)
+	assertTrue(t, err == nil)
+	assertEquals(t, "resolved", entry.Title)
+	entry.ResolvedAt = time.Time{}
+	assertTrue(t, repo.unarchiveEntry(entry) == nil)
+	assertTrue(t, len(repo.readArchivedEntries()) == 0)
+	reopened, err := repo.readEntryById(resolved.Id)
+	assertTrue(t, err == nil)
+	assertTrue(t, reopened.ResolvedAt.IsZero())
+
+	stored, _ := repo.readEntryById(active.Id)
+	repo.archiveEntry(stored)
+	assertTrue(t, repo.insertEntry(todo{Title: "active", Id: uuid.New(), Due: time.Now()}) == nil)
+	archivedClash, err := repo.readArchivedEntryById(active.Id)
+	assertTrue(t, err == nil)
+	assertTrue(t, repo.unarchiveEntry(archivedClash) != nil)
+}
@@ -1,29 +1,102 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 type restClient struct {
-	baseUrl string
+	baseUrl    string
+	token      string
+	httpClient *http.Client
 }
 
-func newRestClient(baseUrl string) *restClient {
-	return &restClient{baseUrl: baseUrl}
+func newRestClient(config config) (*restClient, error) {
+	transport, err := newRemoteTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	return &restClient{baseUrl: config.RemoteBaseUrl, token: config.RemoteToken, httpClient: &http.Client{Transport: transport}}, nil
+}
+
+// requestOption adapts a request before it is sent
+type requestOption func(req *http.Request)
+
+// ifMatch makes the server change a todo only if it still has the given revision
+func ifMatch(revision int) requestOption {
+	return func(req *http.Request) {
+		req.Header.Set("If-Match", etagOf(revision))
+	}
+}
+
+// do sends the request, authorized by the bearer token if there is one
+func (client *restClient) do(req *http.Request, options ...requestOption) (*http.Response, error) {
+	if len(client.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+client.token)
+	}
+	for _, option := range options {
+		option(req)
+	}
+	return client.httpClient.Do(req)
 }
 
 func (client *restClient) doGet(path string, responseTarget interface{}) error {
-	res, err := http.Get(client.baseUrl + path)
+	req, err := http.NewRequest("GET", client.baseUrl+path, nil)
+	if err != nil {
+		return err
+	}
+	res, err := client.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return responseError(res)
+	}
+	if res.StatusCode == http.StatusNoContent || res.StatusCode == http.StatusCreated {
+		return nil
+	}
+	if responseTarget == nil {
+		return nil
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(data, responseTarget)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (client *restClient) doPost(path string, requestBody interface{}, responseTarget interface{}, options ...requestOption) error {
+	requestData := make([]byte, 0)
+	if requestBody != nil {
+		data, err := json.Marshal(requestBody)
+		if err != nil {
+			return err
+		}
+		requestData = data
+	}
+	req, err := http.NewRequest("POST", client.baseUrl+path, bytes.NewBuffer(requestData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.do(req, options...)
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
 	if res.StatusCode >= http.StatusBadRequest {
-		return errors.New("http response failed with " + strconv.Itoa(res.StatusCode))
+		return responseError(res)
 	}
 	if res.StatusCode == http.StatusNoContent || res.StatusCode == http.StatusCreated {
 		return nil
@@ -42,7 +115,7 @@ func (client *restClient) doGet(path string, responseTarget interface{}) error {
 	return nil
 }
 
-func (client *restClient) doPost(path string, requestBody interface{}, responseTarget interface{}) error {
+func (client *restClient) doPut(path string, requestBody interface{}, responseTarget interface{}, options ...requestOption) error {
 	requestData := make([]byte, 0)
 	if requestBody != nil {
 		data, err := json.Marshal(requestBody)
@@ -51,12 +124,18 @@ func (client *restClient) doPost(path string, requestBody interface{}, responseT
 		}
 		requestData = data
 	}
-	res, err := http.Post(client.baseUrl+path, "application/json", bytes.NewBuffer(requestData))
+	req, err := http.NewRequest("PUT", client.baseUrl+path, bytes.NewBuffer(requestData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.do(req, options...)
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
 	if res.StatusCode >= http.StatusBadRequest {
-		return errors.New("http response failed with " + strconv.Itoa(res.StatusCode))
+		return responseError(res)
 	}
 	if res.StatusCode == http.StatusNoContent || res.StatusCode == http.StatusCreated {
 		return nil
@@ -75,17 +154,78 @@ func (client *restClient) doPost(path string, requestBody interface{}, responseT
 	return nil
 }
 
-func (client *restClient) doDelete(path string) error {
+func (client *restClient) doDelete(path string, options ...requestOption) error {
 	req, err := http.NewRequest("DELETE", client.baseUrl+path, nil)
-	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
+	res, err := client.do(req, options...)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
 	if res.StatusCode >= http.StatusBadRequest {
-		return errors.New("http response failed with " + strconv.Itoa(res.StatusCode))
+		return responseError(res)
 	}
 	if res.StatusCode == http.StatusNoContent || res.StatusCode == http.StatusCreated {
 		return nil
 	}
 	return nil
 }
+
+// doStream reads the server-sent events of the path, handing each one to handle until the stream ends
+func (client *restClient) doStream(path string, lastEventId string, handle func(id string, event string, data string)) error {
+	req, err := http.NewRequest("GET", client.baseUrl+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if len(lastEventId) > 0 {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+	res, err := client.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return responseError(res)
+	}
+	var id, event string
+	data := make([]string, 0)
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 {
+			if len(data) > 0 {
+				handle(id, event, strings.Join(data, "\n"))
+			}
+			event, data = "", make([]string, 0)
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	return scanner.Err()
+}
+
+func responseError(res *http.Response) error {
+	if res.StatusCode == http.StatusUnauthorized {
+		return errors.New("http response failed with 401, the remote token is missing or invalid")
+	}
+	if res.StatusCode == http.StatusPreconditionFailed {
+		return errConflict
+	}
+	return errors.New("http response failed with " + strconv.Itoa(res.StatusCode))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,51 +9,161 @@ import (
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"io"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type restServer struct {
 	app       app
+	events    *eventBus
+	report    reportOptions
 	listeners []restServerListener
+	// mutationLock makes checking the revision of a todo and changing it one step
+	mutationLock sync.Mutex
 }
 
 type restServerListener struct {
 	path    string
 	handler func(http.ResponseWriter, *http.Request)
+	// streaming listeners keep the response open, setting a write deadline on their own for each write
+	streaming bool
+	// admin listeners act on all todos at once, only served when tokens are configured
+	admin bool
 }
 
 func listenerOf(path string, handler func(http.ResponseWriter, *http.Request)) restServerListener {
 	return restServerListener{path: path, handler: handler}
 }
 
-func newRestServer(app app) *restServer {
-	rs := &restServer{app: app}
+func streamingListenerOf(path string, handler func(http.ResponseWriter, *http.Request)) restServerListener {
+	return restServerListener{path: path, handler: handler, streaming: true}
+}
+
+// adminListenerOf creates a listener served only with tokens configured and given adminWriteTimeout, as backups
+// and restores of many todos may take long
+func adminListenerOf(path string, handler func(http.ResponseWriter, *http.Request)) restServerListener {
+	return restServerListener{path: path, handler: handler, admin: true}
+}
+
+// requestTimeout cuts the responses of the listeners neither streaming nor admin
+const requestTimeout = 15 * time.Second
+
+// adminWriteTimeout cuts the responses of the admin listeners
+const adminWriteTimeout = 5 * time.Minute
+
+func newRestServer(app app, events *eventBus, report reportOptions) *restServer {
+	rs := &restServer{app: app, events: events, report: report}
 	listeners := make([]restServerListener, 0)
 	listeners = append(listeners, listenerOf("/todos", rs.TodosHandler))
 	listeners = append(listeners, listenerOf("/todos/{todoId}", rs.TodoHandler))
 	listeners = append(listeners, listenerOf("/todos/{todoId}/notified", rs.TodoNotifiedHandler))
+	listeners = append(listeners, listenerOf("/todos/{todoId}/reminded", rs.TodoRemindedHandler))
 	listeners = append(listeners, listenerOf("/todos/{todoId}/resolved", rs.TodoResolvedHandler))
 	listeners = append(listeners, listenerOf("/todos/{todoId}/due", rs.TodoDueHandler))
+	listeners = append(listeners, listenerOf("/todos/{todoId}/priority", rs.TodoPriorityHandler))
+	listeners = append(listeners, listenerOf("/todos/{todoId}/checklist", rs.TodoChecklistHandler))
+	listeners = append(listeners, listenerOf("/todos/{todoId}/checklist/{index}", rs.TodoChecklistItemHandler))
+	listeners = append(listeners, listenerOf("/todos/{todoId}/blockers", rs.TodoBlockersHandler))
+	listeners = append(listeners, listenerOf("/todos/{todoId}/blockers/{blockerId}", rs.TodoBlockerHandler))
 	listeners = append(listeners, listenerOf("/search", rs.SearchHandler))
+	listeners = append(listeners, listenerOf("/archive", rs.ArchiveHandler))
+	listeners = append(listeners, listenerOf("/archive/search", rs.ArchiveSearchHandler))
+	listeners = append(listeners, listenerOf("/archive/{todoId}/reopened", rs.ArchiveReopenedHandler))
+	listeners = append(listeners, streamingListenerOf("/events", rs.EventsHandler))
+	listeners = append(listeners, listenerOf("/calendar.ics", rs.CalendarHandler))
+	listeners = append(listeners, listenerOf("/report.html", rs.ReportHandler))
+	listeners = append(listeners, adminListenerOf("/admin/backup", rs.BackupHandler))
+	listeners = append(listeners, adminListenerOf("/admin/restore", rs.RestoreHandler))
 	rs.listeners = listeners
 	return rs
 }
 
+// register adds the listeners to the router, cutting the responses of the admin ones after adminWriteTimeout and of
+// the other ones but the streaming ones after requestTimeout. The admin ones are refused when no tokens are given.
+func (rs *restServer) register(r *mux.Router, tokens []string) {
+	for _, listener := range rs.listeners {
+		if listener.admin && len(tokens) == 0 {
+			r.HandleFunc(listener.path, AdminDisabledHandler)
+		} else if listener.admin {
+			r.HandleFunc(listener.path, withWriteTimeout(listener.handler, adminWriteTimeout))
+		} else if listener.streaming {
+			r.HandleFunc(listener.path, listener.handler)
+		} else {
+			r.Handle(listener.path, http.TimeoutHandler(http.HandlerFunc(listener.handler), requestTimeout, "Request timed out"))
+		}
+	}
+}
+
+// connContextKey keeps the connection of a request in its context, for handlers to set write deadlines of their own
+type connContextKey struct{}
+
+// withConn is the ConnContext of the rest server
+func withConn(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+// setWriteDeadline cuts writing the response after the timeout from now, a timeout of 0 lifting the deadline for the
+// connection to serve further requests. Without the connection in the context of the request it does nothing.
+func setWriteDeadline(r *http.Request, timeout time.Duration) {
+	conn, ok := r.Context().Value(connContextKey{}).(net.Conn)
+	if !ok {
+		return
+	}
+	deadline := time.Time{}
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	_ = conn.SetWriteDeadline(deadline)
+}
+
+// withWriteTimeout cuts writing the response of the handler after the timeout, without keeping the response in
+// memory like http.TimeoutHandler
+func withWriteTimeout(handler func(http.ResponseWriter, *http.Request), timeout time.Duration) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setWriteDeadline(r, timeout)
+		defer setWriteDeadline(r, 0)
+		handler(w, r)
+	}
+}
+
+// AdminDisabledHandler refuses the admin routes of a server accepting requests without a token
+func AdminDisabledHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Refusing admin request without tokens configured: %s %s\n", r.Method, r.URL.Path)
+	w.WriteHeader(http.StatusForbidden)
+	w.Write([]byte("Admin routes are disabled, configure rest_tokens or rest_token_file to enable them"))
+}
+
 type TodosResponse struct {
 	Todos      []todoModel `json:"todos"`
 	ShortIdMap ShortIdMap  `json:"shortIdMap"`
 }
 
 type AddBody struct {
-	Title   string    `json:"title"`
-	Details string    `json:"details"`
-	Due     time.Time `json:"due"`
+	Title      string           `json:"title"`
+	Details    string           `json:"details"`
+	Due        time.Time        `json:"due"`
+	Recurrence *recurrenceModel `json:"recurrence,omitempty"`
+	Tags       []string         `json:"tags,omitempty"`
+	Project    string           `json:"project,omitempty"`
+	Priority   string           `json:"priority,omitempty"`
+	Reminders  []string         `json:"reminders,omitempty"`
+	// NotificationType defaults to 'once', 'repeat' needing a NotificationInterval
+	NotificationType     string               `json:"notificationType,omitempty"`
+	NotificationInterval string               `json:"notificationInterval,omitempty"`
+	EscalateAfter        int                  `json:"escalateAfter,omitempty"`
+	Checklist            []checklistItemModel `json:"checklist,omitempty"`
+	AutoResolve          bool                 `json:"autoResolve,omitempty"`
+	// CreatedAt defaults to now, ResolvedAt adds the todo as resolved right into the archive
+	CreatedAt  time.Time `json:"createdAt,omitempty"`
+	ResolvedAt time.Time `json:"resolvedAt,omitempty"`
 }
 
 func (rs *restServer) TodosHandler(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.RequestURI)
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
 	method, _, err := rs.resolveMethodAndContentType(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -60,7 +171,19 @@ func (rs *restServer) TodosHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if strings.EqualFold(method, "GET") {
-		todos, shortIdMap := rs.app.findAll()
+		location, err := parseQueryLocation(r.URL.Query().Get("location"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		filter := todoFilter{Tags: r.URL.Query()["tag"], Project: r.URL.Query().Get("project"), Ready: strings.EqualFold(r.URL.Query().Get("ready"), "true"), Query: r.URL.Query().Get("query"), Location: location}
+		if _, err := parseTodoQuery(filter.Query, location); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		todos, shortIdMap := rs.app.findAll(filter)
 		response := TodosResponse{Todos: todos, ShortIdMap: shortIdMap}
 		jsonResponse, err := json.Marshal(response)
 		if err != nil {
@@ -90,7 +213,8 @@ func (rs *restServer) TodosHandler(w http.ResponseWriter, r *http.Request) {
 			w.Write([]byte("A due date must be provided"))
 			return
 		}
-		err := rs.app.add(addBody.Title, addBody.Details, addBody.Due)
+		err := rs.app.add(todoModel{Title: addBody.Title, Details: addBody.Details, Due: addBody.Due, Recurrence: addBody.Recurrence, Tags: addBody.Tags, Project: addBody.Project, Priority: addBody.Priority, Notification: notificationModel{Type: addBody.NotificationType, Interval: addBody.NotificationInterval, EscalateAfter: addBody.EscalateAfter, Reminders: reminderModelsOf(addBody.Reminders)},
+			Checklist: addBody.Checklist, AutoResolve: addBody.AutoResolve, CreatedAt: addBody.CreatedAt, ResolvedAt: addBody.ResolvedAt})
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			w.Write([]byte(err.Error()))
@@ -101,7 +225,7 @@ func (rs *restServer) TodosHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (rs *restServer) TodoHandler(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.RequestURI)
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
 	method, _, err := rs.resolveMethodAndContentType(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -133,9 +257,15 @@ func (rs *restServer) TodoHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etagOf(todo.Revision))
 		w.WriteHeader(http.StatusOK)
 		w.Write(jsonTodo)
 	} else if strings.EqualFold(method, "DELETE") {
+		rs.mutationLock.Lock()
+		defer rs.mutationLock.Unlock()
+		if !rs.checkRevision(w, r, todoId) {
+			return
+		}
 		err := rs.app.delete(todoId)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
@@ -143,11 +273,90 @@ func (rs *restServer) TodoHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
+	} else if strings.EqualFold(method, "PUT") || strings.EqualFold(method, "PATCH") {
+		updateBody := &UpdateBody{}
+		err = rs.parseRequestBody(r.Body, updateBody)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		rs.mutationLock.Lock()
+		defer rs.mutationLock.Unlock()
+		if !rs.checkRevision(w, r, todoId) {
+			return
+		}
+		todo, _ := rs.app.find(todoId.String())
+		if todo == nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(fmt.Sprintf("No todo by the id '%s' found", todoId)))
+			return
+		}
+		if strings.EqualFold(method, "PUT") {
+			// a PUT replaces every field but the revision checked above
+			todo = &todoModel{Id: todoId, Revision: todo.Revision}
+		}
+		if strings.EqualFold(method, "PUT") && (updateBody.Title == nil || updateBody.Due == nil) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("A title and a due date must be provided"))
+			return
+		}
+		updateBody.applyTo(todo)
+		err = rs.app.update(*todo)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// UpdateBody holds the fields to overwrite, PATCH keeps the current value of fields left out
+type UpdateBody struct {
+	Title                *string               `json:"title,omitempty"`
+	Details              *string               `json:"details,omitempty"`
+	Due                  *time.Time            `json:"due,omitempty"`
+	NotificationType     *string               `json:"notificationType,omitempty"`
+	NotificationInterval *string               `json:"notificationInterval,omitempty"`
+	EscalateAfter        *int                  `json:"escalateAfter,omitempty"`
+	Reminders            *[]string             `json:"reminders,omitempty"`
+	Checklist            *[]checklistItemModel `json:"checklist,omitempty"`
+	AutoResolve          *bool                 `json:"autoResolve,omitempty"`
+}
+
+func (body *UpdateBody) applyTo(todo *todoModel) {
+	if body.Title != nil {
+		todo.Title = *body.Title
+	}
+	if body.Details != nil {
+		todo.Details = *body.Details
+	}
+	if body.Due != nil {
+		todo.Due = *body.Due
+	}
+	if body.NotificationType != nil {
+		todo.Notification.Type = *body.NotificationType
+	}
+	if body.NotificationInterval != nil {
+		todo.Notification.Interval = *body.NotificationInterval
+	}
+	if body.EscalateAfter != nil {
+		todo.Notification.EscalateAfter = *body.EscalateAfter
+	}
+	if body.Reminders != nil {
+		todo.Notification.Reminders = reminderModelsOf(*body.Reminders)
+	}
+	if body.Checklist != nil {
+		todo.Checklist = *body.Checklist
+	}
+	if body.AutoResolve != nil {
+		todo.AutoResolve = *body.AutoResolve
 	}
 }
 
 func (rs *restServer) TodoNotifiedHandler(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.RequestURI)
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
 	method, _, err := rs.resolveMethodAndContentType(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -168,6 +377,11 @@ func (rs *restServer) TodoNotifiedHandler(w http.ResponseWriter, r *http.Request
 			return
 		}
 	}
+	rs.mutationLock.Lock()
+	defer rs.mutationLock.Unlock()
+	if !rs.checkRevision(w, r, todoId) {
+		return
+	}
 	err = rs.app.markNotified(todoId)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -177,8 +391,55 @@ func (rs *restServer) TodoNotifiedHandler(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusNoContent)
 }
 
+type RemindedBody struct {
+	Before string `json:"before"`
+}
+
+func (rs *restServer) TodoRemindedHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	method, _, err := rs.resolveMethodAndContentType(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !strings.EqualFold(method, "POST") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'POST'"))
+		return
+	}
+	vars := mux.Vars(r)
+	todoId, err := uuid.Parse(vars["todoId"])
+	if err != nil {
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Id is not a valid UUID"))
+			return
+		}
+	}
+	remindedBody := &RemindedBody{}
+	err = rs.parseRequestBody(r.Body, remindedBody)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	rs.mutationLock.Lock()
+	defer rs.mutationLock.Unlock()
+	if !rs.checkRevision(w, r, todoId) {
+		return
+	}
+	err = rs.app.markReminded(todoId, remindedBody.Before)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (rs *restServer) TodoResolvedHandler(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.RequestURI)
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
 	method, _, err := rs.resolveMethodAndContentType(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -199,6 +460,11 @@ func (rs *restServer) TodoResolvedHandler(w http.ResponseWriter, r *http.Request
 			return
 		}
 	}
+	rs.mutationLock.Lock()
+	defer rs.mutationLock.Unlock()
+	if !rs.checkRevision(w, r, todoId) {
+		return
+	}
 	err = rs.app.resolve(todoId)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -213,7 +479,7 @@ type DueBody struct {
 }
 
 func (rs *restServer) TodoDueHandler(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.RequestURI)
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
 	method, _, err := rs.resolveMethodAndContentType(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -246,6 +512,11 @@ func (rs *restServer) TodoDueHandler(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("A due date must be provided"))
 		return
 	}
+	rs.mutationLock.Lock()
+	defer rs.mutationLock.Unlock()
+	if !rs.checkRevision(w, r, todoId) {
+		return
+	}
 	err = rs.app.setNewDue(todoId, dueBody.Due)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -255,14 +526,12 @@ func (rs *restServer) TodoDueHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-type SearchBody struct {
-	SearchFor      string    `json:"searchFor"`
-	DueBefore      time.Time `json:"dueBefore"`
-	NotifiedBefore time.Time `json:"notifiedBefore"`
+type PriorityBody struct {
+	Priority string `json:"priority"`
 }
 
-func (rs *restServer) SearchHandler(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.RequestURI)
+func (rs *restServer) TodoPriorityHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
 	method, _, err := rs.resolveMethodAndContentType(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -274,68 +543,723 @@ func (rs *restServer) SearchHandler(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Method must be 'POST'"))
 		return
 	}
-	searchBody := &SearchBody{}
-	err = rs.parseRequestBody(r.Body, searchBody)
+	vars := mux.Vars(r)
+	todoId, err := uuid.Parse(vars["todoId"])
+	if err != nil {
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Id is not a valid UUID"))
+			return
+		}
+	}
+	priorityBody := &PriorityBody{}
+	err = rs.parseRequestBody(r.Body, priorityBody)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
 		return
 	}
-	if len(searchBody.SearchFor) == 0 && searchBody.DueBefore.IsZero() && searchBody.NotifiedBefore.IsZero() {
+	rs.mutationLock.Lock()
+	defer rs.mutationLock.Unlock()
+	if !rs.checkRevision(w, r, todoId) {
+		return
+	}
+	err = rs.app.setPriority(todoId, priorityBody.Priority)
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("A search value must be provided"))
+		w.Write([]byte(err.Error()))
 		return
 	}
-	todosResponse := make([]todoModel, 0)
-	var shortIdMapResponse ShortIdMap = make(map[string]string)
-	if len(searchBody.SearchFor) > 0 {
-		todo, shortId := rs.app.find(searchBody.SearchFor)
-		if todo != nil {
-			todosResponse = append(todosResponse, *todo)
-			shortIdMapResponse[(*todo).Id.String()] = shortId
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type ChecklistItemBody struct {
+	Text string `json:"text,omitempty"`
+	Done bool   `json:"done"`
+}
+
+func (rs *restServer) TodoChecklistHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	method, _, err := rs.resolveMethodAndContentType(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !strings.EqualFold(method, "POST") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'POST'"))
+		return
+	}
+	vars := mux.Vars(r)
+	todoId, err := uuid.Parse(vars["todoId"])
+	if err != nil {
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Id is not a valid UUID"))
+			return
 		}
-	} else if !searchBody.DueBefore.IsZero() {
-		todosResponse, shortIdMapResponse = rs.app.findWhereDueBefore(searchBody.DueBefore)
-	} else if !searchBody.NotifiedBefore.IsZero() {
-		todosResponse, shortIdMapResponse = rs.app.findToBeNotifiedByDueBefore(searchBody.NotifiedBefore)
 	}
-	response := TodosResponse{Todos: todosResponse, ShortIdMap: shortIdMapResponse}
-	jsonResponse, err := json.Marshal(response)
+	itemBody := &ChecklistItemBody{}
+	err = rs.parseRequestBody(r.Body, itemBody)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
-		log.Errorf("Error marshalling JSON: %v", err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(jsonResponse)
+	rs.mutationLock.Lock()
+	defer rs.mutationLock.Unlock()
+	if !rs.checkRevision(w, r, todoId) {
+		return
+	}
+	err = rs.app.addChecklistItem(todoId, itemBody.Text)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (rs *restServer) parseRequestBody(bodyReader io.ReadCloser, parseTarget interface{}) error {
-	requestBody, err := io.ReadAll(bodyReader)
+// TodoChecklistItemHandler checks or unchecks the item at the 1-based index by PUT or PATCH and removes it by DELETE
+func (rs *restServer) TodoChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	method, _, err := rs.resolveMethodAndContentType(r)
 	if err != nil {
-		log.Errorf("Error reading rest body: %v", err)
-		return err
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
 	}
-	err = json.Unmarshal(requestBody, parseTarget)
+	vars := mux.Vars(r)
+	todoId, err := uuid.Parse(vars["todoId"])
 	if err != nil {
-		log.Errorf("Error parsing rest body: %v", err)
-		return err
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Id is not a valid UUID"))
+			return
+		}
 	}
-	return nil
+	index, err := strconv.Atoi(vars["index"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Index is not a number"))
+		return
+	}
+	rs.mutationLock.Lock()
+	defer rs.mutationLock.Unlock()
+	if !rs.checkRevision(w, r, todoId) {
+		return
+	}
+	if strings.EqualFold(method, "PUT") || strings.EqualFold(method, "PATCH") {
+		itemBody := &ChecklistItemBody{}
+		err = rs.parseRequestBody(r.Body, itemBody)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		err = rs.app.checkChecklistItem(todoId, index, itemBody.Done)
+	} else if strings.EqualFold(method, "DELETE") {
+		err = rs.app.removeChecklistItem(todoId, index)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'PUT', 'PATCH' or 'DELETE'"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (rs *restServer) resolveMethodAndContentType(r *http.Request) (string, string, error) {
-	method := r.Method
-	contentType := r.Header.Get("Content-Type")
-	if strings.EqualFold(method, "POST") {
-		if len(contentType) == 0 {
-			return "", "", errors.New("Content-Type is required")
-		}
-		if !strings.EqualFold(contentType, "application/json") {
-			return "", "", errors.New("Content-Type must be 'application/json'")
+type BlockerBody struct {
+	BlockerId uuid.UUID `json:"blockerId"`
+}
+
+func (rs *restServer) TodoBlockersHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	method, _, err := rs.resolveMethodAndContentType(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !strings.EqualFold(method, "POST") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'POST'"))
+		return
+	}
+	vars := mux.Vars(r)
+	todoId, err := uuid.Parse(vars["todoId"])
+	if err != nil {
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Id is not a valid UUID"))
+			return
 		}
 	}
-	return method, contentType, nil
+	blockerBody := &BlockerBody{}
+	err = rs.parseRequestBody(r.Body, blockerBody)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	rs.mutationLock.Lock()
+	defer rs.mutationLock.Unlock()
+	if !rs.checkRevision(w, r, todoId) {
+		return
+	}
+	err = rs.app.block(todoId, blockerBody.BlockerId)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rs *restServer) TodoBlockerHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	method, _, err := rs.resolveMethodAndContentType(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !strings.EqualFold(method, "DELETE") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'DELETE'"))
+		return
+	}
+	vars := mux.Vars(r)
+	todoId, err := uuid.Parse(vars["todoId"])
+	if err != nil {
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Id is not a valid UUID"))
+			return
+		}
+	}
+	blockerId, err := uuid.Parse(vars["blockerId"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Blocker id is not a valid UUID"))
+		return
+	}
+	rs.mutationLock.Lock()
+	defer rs.mutationLock.Unlock()
+	if !rs.checkRevision(w, r, todoId) {
+		return
+	}
+	err = rs.app.unblock(todoId, blockerId)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type SearchBody struct {
+	SearchFor      string    `json:"searchFor"`
+	DueBefore      time.Time `json:"dueBefore"`
+	NotifiedBefore time.Time `json:"notifiedBefore"`
+	RemindedAt     time.Time `json:"remindedAt"`
+	Tags           []string  `json:"tags,omitempty"`
+	Project        string    `json:"project,omitempty"`
+	Ready          bool      `json:"ready,omitempty"`
+	// Query is a filter expression like 'due<2026-11-01 and title~deploy and not notified'
+	Query string `json:"query,omitempty"`
+	// Location tells the days and times of the query, named like 'Europe/Berlin' or by its offset like '+02:00'
+	Location string `json:"location,omitempty"`
+}
+
+func (rs *restServer) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	method, _, err := rs.resolveMethodAndContentType(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !strings.EqualFold(method, "POST") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'POST'"))
+		return
+	}
+	searchBody := &SearchBody{}
+	err = rs.parseRequestBody(r.Body, searchBody)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	location, err := parseQueryLocation(searchBody.Location)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	filter := todoFilter{Tags: searchBody.Tags, Project: searchBody.Project, Ready: searchBody.Ready, Query: searchBody.Query, Location: location}
+	if len(searchBody.SearchFor) == 0 && searchBody.DueBefore.IsZero() && searchBody.NotifiedBefore.IsZero() && searchBody.RemindedAt.IsZero() && filter.isEmpty() {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("A search value must be provided"))
+		return
+	}
+	if _, err := parseTodoQuery(filter.Query, location); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	// every criterion given narrows the todos down, instead of the first one deciding alone
+	todosResponse, shortIdMapResponse := rs.app.findAll(filter)
+	if len(searchBody.SearchFor) > 0 {
+		// every match is returned, best first, for the client to choose from
+		found, _ := rs.app.findMatching(searchBody.SearchFor)
+		todosResponse = intersectTodos(found, todosResponse)
+	}
+	if !searchBody.DueBefore.IsZero() {
+		dueBefore, _ := rs.app.findWhereDueBefore(searchBody.DueBefore, filter)
+		todosResponse = intersectTodos(todosResponse, dueBefore)
+	}
+	if !searchBody.NotifiedBefore.IsZero() {
+		toBeNotified, _ := rs.app.findToBeNotifiedByDueBefore(searchBody.NotifiedBefore)
+		todosResponse = intersectTodos(todosResponse, toBeNotified)
+	}
+	if !searchBody.RemindedAt.IsZero() {
+		toBeReminded, _ := rs.app.findToBeRemindedAt(searchBody.RemindedAt)
+		todosResponse = intersectTodos(todosResponse, toBeReminded)
+	}
+	response := TodosResponse{Todos: todosResponse, ShortIdMap: shortIdMapResponse}
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		log.Errorf("Error marshalling JSON: %v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+// intersectTodos keeps the todos also present in the other todos
+func intersectTodos(todos []todoModel, others []todoModel) []todoModel {
+	otherIds := make(map[uuid.UUID]bool)
+	for _, other := range others {
+		otherIds[other.Id] = true
+	}
+	intersection := make([]todoModel, 0, len(todos))
+	for _, entry := range todos {
+		if otherIds[entry.Id] {
+			intersection = append(intersection, entry)
+		}
+	}
+	return intersection
+}
+
+func (rs *restServer) ArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	method, _, err := rs.resolveMethodAndContentType(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !strings.EqualFold(method, "GET") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'GET'"))
+		return
+	}
+	var resolvedFrom, resolvedTo time.Time
+	if len(r.URL.Query().Get("resolvedFrom")) > 0 {
+		resolvedFrom, err = time.Parse(time.RFC3339, r.URL.Query().Get("resolvedFrom"))
+	}
+	if err == nil && len(r.URL.Query().Get("resolvedTo")) > 0 {
+		resolvedTo, err = time.Parse(time.RFC3339, r.URL.Query().Get("resolvedTo"))
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Resolved range must be given as RFC3339 timestamps"))
+		return
+	}
+	todos, shortIdMap := rs.app.findArchived(resolvedFrom, resolvedTo)
+	response := TodosResponse{Todos: todos, ShortIdMap: shortIdMap}
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		log.Errorf("Error marshalling JSON: %v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (rs *restServer) ArchiveSearchHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	method, _, err := rs.resolveMethodAndContentType(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !strings.EqualFold(method, "POST") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'POST'"))
+		return
+	}
+	searchBody := &SearchBody{}
+	err = rs.parseRequestBody(r.Body, searchBody)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if len(searchBody.SearchFor) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("A search value must be provided"))
+		return
+	}
+	todosResponse := make([]todoModel, 0)
+	var shortIdMapResponse ShortIdMap = make(map[string]string)
+	todo, shortId := rs.app.findInArchive(searchBody.SearchFor)
+	if todo != nil {
+		todosResponse = append(todosResponse, *todo)
+		shortIdMapResponse[(*todo).Id.String()] = shortId
+	}
+	response := TodosResponse{Todos: todosResponse, ShortIdMap: shortIdMapResponse}
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		log.Errorf("Error marshalling JSON: %v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (rs *restServer) ArchiveReopenedHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	method, _, err := rs.resolveMethodAndContentType(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !strings.EqualFold(method, "POST") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'POST'"))
+		return
+	}
+	vars := mux.Vars(r)
+	todoId, err := uuid.Parse(vars["todoId"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Id is not a valid UUID"))
+		return
+	}
+	rs.mutationLock.Lock()
+	defer rs.mutationLock.Unlock()
+	archived, _ := rs.app.findInArchive(todoId.String())
+	if archived != nil && archived.Id != todoId {
+		archived = nil
+	}
+	if !rs.checkRevisionOf(w, r, archived) {
+		return
+	}
+	err = rs.app.reopen(todoId)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkRevision answers 428 to a request without If-Match and 412 if If-Match is not the revision of the todo,
+// returning whether to go on with the request
+func (rs *restServer) checkRevision(w http.ResponseWriter, r *http.Request, todoId uuid.UUID) bool {
+	if len(r.Header.Get("If-Match")) == 0 {
+		return rs.checkRevisionOf(w, r, nil)
+	}
+	todo, _ := rs.app.find(todoId.String())
+	return rs.checkRevisionOf(w, r, todo)
+}
+
+// checkRevisionOf checks If-Match like checkRevision against the todo as found, active or archived
+func (rs *restServer) checkRevisionOf(w http.ResponseWriter, r *http.Request, todo *todoModel) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if len(ifMatch) == 0 {
+		w.WriteHeader(http.StatusPreconditionRequired)
+		w.Write([]byte("If-Match with the ETag of the todo is required"))
+		return false
+	}
+	if todo == nil {
+		// left to the handler answering as for any unknown todo
+		return true
+	}
+	for _, etag := range strings.Split(ifMatch, ",") {
+		etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+		if etag == "*" || etag == etagOf(todo.Revision) {
+			return true
+		}
+	}
+	w.Header().Set("ETag", etagOf(todo.Revision))
+	w.WriteHeader(http.StatusPreconditionFailed)
+	w.Write([]byte(fmt.Sprintf("The todo was changed meanwhile, its revision is %d now", todo.Revision)))
+	return false
+}
+
+func etagOf(revision int) string {
+	return fmt.Sprintf("\"%d\"", revision)
+}
+
+func (rs *restServer) parseRequestBody(bodyReader io.ReadCloser, parseTarget interface{}) error {
+	requestBody, err := io.ReadAll(bodyReader)
+	if err != nil {
+		log.Errorf("Error reading rest body: %v", err)
+		return err
+	}
+	err = json.Unmarshal(requestBody, parseTarget)
+	if err != nil {
+		log.Errorf("Error parsing rest body: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (rs *restServer) resolveMethodAndContentType(r *http.Request) (string, string, error) {
+	method := r.Method
+	contentType := r.Header.Get("Content-Type")
+	if strings.EqualFold(method, "POST") || strings.EqualFold(method, "PUT") || strings.EqualFold(method, "PATCH") {
+		if len(contentType) == 0 {
+			return "", "", errors.New("Content-Type is required")
+		}
+		if !strings.EqualFold(contentType, "application/json") {
+			return "", "", errors.New("Content-Type must be 'application/json'")
+		}
+	}
+	return method, contentType, nil
+}
+
+// EventsHandler streams the changes as server-sent events, resuming after the id given by the Last-Event-ID header
+// or the lastEventId query parameter
+func (rs *restServer) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	if !strings.EqualFold(r.Method, "GET") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'GET'"))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok || rs.events == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		w.Write([]byte("Streaming events not supported"))
+		return
+	}
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if len(lastEventId) == 0 {
+		lastEventId = r.URL.Query().Get("lastEventId")
+	}
+
+	replay, subscriber := rs.events.subscribe(lastEventId)
+	defer rs.events.unsubscribe(subscriber)
+	// each write has to get through in time, a client not reading any more ending the stream
+	setWriteDeadline(r, eventStreamWriteTimeout)
+	defer setWriteDeadline(r, 0)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for _, event := range replay {
+		if writeServerSentEvent(w, event) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		setWriteDeadline(r, eventStreamWriteTimeout)
+		select {
+		case event, ok := <-subscriber:
+			if !ok {
+				return
+			}
+			if writeServerSentEvent(w, event) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+const eventStreamKeepAlive = 15 * time.Second
+
+// eventStreamWriteTimeout cuts the event stream when writing an event or keep-alive takes longer
+const eventStreamWriteTimeout = 30 * time.Second
+
+func writeServerSentEvent(w io.Writer, event changeEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+	return err
+}
+
+// CalendarHandler serves the todos as iCalendar feed, optionally filtered by tag and project, with resolved todos if
+// archived is true and as events if component is 'event'
+func (rs *restServer) CalendarHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	if !strings.EqualFold(r.Method, "GET") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'GET'"))
+		return
+	}
+	component, err := parseIcsComponent(r.URL.Query().Get("component"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	filter := todoFilter{Tags: r.URL.Query()["tag"], Project: r.URL.Query().Get("project")}
+	archived := strings.EqualFold(r.URL.Query().Get("archived"), "true")
+	entries := collectExportEntries(rs.app, filter, archived)
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	err = writeIcs(w, entries, component, time.Now())
+	if err != nil {
+		log.Errorf("Error writing calendar: %v", err)
+	}
+}
+
+// ReportHandler serves the report as html page, the query parameters sections and window overriding the configured
+// ones and tag and project filtering the todos
+func (rs *restServer) ReportHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	if !strings.EqualFold(r.Method, "GET") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'GET'"))
+		return
+	}
+	options := rs.report
+	var err error
+	sections := r.URL.Query().Get("sections")
+	window := r.URL.Query().Get("window")
+	if len(sections) > 0 || len(window) > 0 {
+		options, err = overrideReportOptions(rs.report, sections, window)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+	}
+	filter := todoFilter{Tags: r.URL.Query()["tag"], Project: r.URL.Query().Get("project")}
+	data := buildReport(rs.app, filter, options, time.Now(), time.Local)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	err = writeReport(w, data, ReportFormatHtml)
+	if err != nil {
+		log.Errorf("Error writing report: %v", err)
+	}
+}
+
+// BackupHandler serves all active and archived todos as backup bundle, in json or with format 'tar.gz' as archive
+func (rs *restServer) BackupHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	if !strings.EqualFold(r.Method, "GET") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'GET'"))
+		return
+	}
+	format, err := parseBackupFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	bundle, err := rs.app.backup()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if format == BackupFormatTarGz {
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", `attachment; filename="todo-backup.tar.gz"`)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(http.StatusOK)
+	err = writeBackup(w, bundle, format)
+	if err != nil {
+		log.Errorf("Error writing backup: %v", err)
+	}
+}
+
+// RestoreHandler restores the posted backup bundle, given in json or as tar.gz archive, merging it into the todos
+// present or with mode 'replace' replacing them
+func (rs *restServer) RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Handling incoming request: %s %s\n", r.Method, r.URL.Path)
+	if !strings.EqualFold(r.Method, "POST") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Method must be 'POST'"))
+		return
+	}
+	mode, err := parseRestoreMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBackupSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			w.Write([]byte(fmt.Sprintf("Backup must not exceed %d bytes", maxBackupSize)))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	bundle, err := readBackup(data)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	rs.mutationLock.Lock()
+	result, err := rs.app.restore(bundle, mode)
+	rs.mutationLock.Unlock()
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	jsonResponse, err := json.Marshal(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		log.Errorf("Error marshalling JSON: %v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
 }
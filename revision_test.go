@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestRestServer(t *testing.T) (*appLocal, *httptest.Server) {
+	app := newTestApp(t)
+	rs := newRestServer(app, nil, reportOptions{Sections: []reportSection{ReportSectionOverdue, ReportSectionWeek, ReportSectionUpcoming, ReportSectionResolved}, Window: 14 * 24 * time.Hour})
+	r := mux.NewRouter()
+	for _, listener := range rs.listeners {
+		r.HandleFunc(listener.path, listener.handler)
+	}
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return app, server
+}
+
+func TestAppLocal_countsRevisions(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "title", Due: time.Now()}) == nil)
+	entry, _ := app.find("title")
+	assertTrue(t, entry.Revision == 1)
+	assertTrue(t, app.setNewDue(entry.Id, time.Now().Add(time.Hour)) == nil)
+	updated, _ := app.find("title")
+	assertTrue(t, updated.Revision == 2)
+
+	entry.Title = "stale"
+	assertTrue(t, errors.Is(app.update(*entry), errConflict))
+	assertTrue(t, app.update(*updated) == nil)
+}
+
+func TestTodoHandlers_requireMatchingRevision(t *testing.T) {
+	app, server := newTestRestServer(t)
+	assertTrue(t, app.add(todoModel{Title: "title", Due: time.Now()}) == nil)
+	entry, _ := app.find("title")
+
+	res, err := http.Get(server.URL + "/todos/" + entry.Id.String())
+	assertTrue(t, err == nil)
+	assertEquals(t, `"1"`, res.Header.Get("ETag"))
+
+	post := func(ifMatch string) *http.Response {
+		req, _ := http.NewRequest("POST", server.URL+"/todos/"+entry.Id.String()+"/due", bytes.NewBufferString(`{"due":"2030-01-02T15:04:00Z"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if len(ifMatch) > 0 {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		res, err := http.DefaultClient.Do(req)
+		assertTrue(t, err == nil)
+		return res
+	}
+	assertTrue(t, post("").StatusCode == http.StatusPreconditionRequired)
+	assertTrue(t, post(`"1"`).StatusCode == http.StatusNoContent)
+	res = post(`"1"`)
+	assertTrue(t, res.StatusCode == http.StatusPreconditionFailed)
+	assertEquals(t, `"2"`, res.Header.Get("ETag"))
+}
+
+func TestAppRemote_surfacesConflicts(t *testing.T) {
+	app, server := newTestRestServer(t)
+	assertTrue(t, app.add(todoModel{Title: "title", Due: time.Now()}) == nil)
+	client, _ := newRestClient(config{RemoteBaseUrl: server.URL})
+	first, second := newAppRemote(client), newAppRemote(client)
+	entry, _ := first.find("title")
+	second.find("title")
+
+	assertTrue(t, first.setNewDue(entry.Id, time.Now().Add(time.Hour)) == nil)
+	assertTrue(t, errors.Is(second.resolve(entry.Id), errConflict))
+	assertTrue(t, first.setPriority(entry.Id, "high") == nil)
+	second.find("title")
+	assertTrue(t, second.resolve(entry.Id) == nil)
+}
+
+func TestAppLocal_updateRequiresRevision(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "title", Due: time.Now()}) == nil)
+	entry, _ := app.find("title")
+	entry.Revision = 0
+	assertTrue(t, errors.Is(app.update(*entry), errRevisionMissing))
+
+	legacy := todo{Id: uuid.New(), Title: "legacy", Due: time.Now()}
+	assertTrue(t, app.repo.insertEntry(legacy) == nil)
+	stored, _ := app.find("legacy")
+	assertTrue(t, stored.Revision == 1)
+	assertTrue(t, app.update(*stored) == nil)
+}
+
+func TestArchiveReopenedHandler_requiresMatchingRevision(t *testing.T) {
+	app, server := newTestRestServer(t)
+	assertTrue(t, app.add(todoModel{Title: "title", Due: time.Now()}) == nil)
+	entry, _ := app.find("title")
+	assertTrue(t, app.resolve(entry.Id) == nil)
+	archived, _ := app.findInArchive(entry.Id.String())
+
+	post := func(ifMatch string) *http.Response {
+		req, _ := http.NewRequest("POST", server.URL+"/archive/"+entry.Id.String()+"/reopened", nil)
+		req.Header.Set("Content-Type", "application/json")
+		if len(ifMatch) > 0 {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		res, err := http.DefaultClient.Do(req)
+		assertTrue(t, err == nil)
+		return res
+	}
+	assertTrue(t, post("").StatusCode == http.StatusPreconditionRequired)
+	assertTrue(t, post(etagOf(archived.Revision+1)).StatusCode == http.StatusPreconditionFailed)
+	assertTrue(t, post(etagOf(archived.Revision)).StatusCode == http.StatusNoContent)
+	reopened, _ := app.find("title")
+	assertTrue(t, reopened != nil)
+}
+
+func TestAppRemote_reopensAtTheArchivedRevision(t *testing.T) {
+	app, server := newTestRestServer(t)
+	assertTrue(t, app.add(todoModel{Title: "title", Due: time.Now()}) == nil)
+	entry, _ := app.find("title")
+	assertTrue(t, app.resolve(entry.Id) == nil)
+	client, _ := newRestClient(config{RemoteBaseUrl: server.URL})
+
+	assertTrue(t, newAppRemote(client).reopen(entry.Id) == nil)
+	reopened, _ := app.find("title")
+	assertTrue(t, reopened != nil)
+}
@@ -2,26 +2,39 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"fyne.io/systray"
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
 
 type server struct {
-	app              app
-	cfg              config
-	runWithTray      bool
-	runAsRestServer  bool
-	ctx              context.Context
-	cancel           context.CancelFunc
-	timeRenderLayout string
+	app                 app
+	cfg                 config
+	notifiers           []notifier
+	escalationNotifiers []notifier
+	calendar            *calendar
+	events              *eventBus
+	heldBack            bool
+	runWithTray         bool
+	runAsRestServer     bool
+	ctx                 context.Context
+	cancel              context.CancelFunc
+	timeRenderLayout    string
+	// reloadMu guards the app, config, notifiers and calendar replaced on SIGHUP
+	reloadMu sync.Mutex
+	// delivering holds the keys of the notifications on their way, as a slow notifier keeps delivering across ticks
+	delivering   map[string]bool
+	deliveringMu sync.Mutex
+	deliveries   sync.WaitGroup
 }
 
 func (server *server) run() {
@@ -41,11 +54,7 @@ func (server *server) run() {
 			case s := <-signalChan:
 				switch s {
 				case syscall.SIGHUP:
-					newConfig := loadConfig()
-					newRepo := newRepositoryMutex(newRepositoryFs(newConfig))
-					newApp := &appLocal{repo: newRepo}
-					server.cfg = newConfig
-					server.app = newApp
+					server.reload(loadConfig())
 				case os.Interrupt:
 					server.cancel()
 					os.Exit(1)
@@ -74,12 +83,31 @@ func (server *server) run() {
 	}()
 }
 
+// reload replaces the app, notifiers and calendar by those of the config, deliveries under way keeping to the
+// previous ones
+func (server *server) reload(newConfig config) {
+	newApp := &appLocal{repo: newRepository(newConfig), events: server.events}
+	newNotifiers, newEscalationNotifiers := newNotifiers(newConfig), newEscalationNotifiers(newConfig)
+	newCalendar, err := newCalendar(newConfig)
+	server.reloadMu.Lock()
+	defer server.reloadMu.Unlock()
+	server.cfg = newConfig
+	server.app = newApp
+	server.notifiers = newNotifiers
+	server.escalationNotifiers = newEscalationNotifiers
+	if err != nil {
+		log.Errorf("Keeping the previous calendar: %s", err)
+	} else {
+		server.calendar = newCalendar
+	}
+}
+
 func (server *server) loop(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-time.Tick(server.cfg.Tick):
+		case <-time.Tick(server.currentConfig().Tick):
 			err := server.handleNotifications()
 			if err != nil {
 				return err
@@ -88,46 +116,191 @@ func (server *server) loop(ctx context.Context) error {
 	}
 }
 
+// pendingNotification is an event waiting to be delivered, mark recording it as sent afterwards
+type pendingNotification struct {
+	key       string
+	event     notificationEvent
+	notifiers []notifier
+	mark      func() error
+}
+
+// currentConfig returns the config as last loaded
+func (server *server) currentConfig() config {
+	server.reloadMu.Lock()
+	defer server.reloadMu.Unlock()
+	return server.cfg
+}
+
+// handleNotifications starts delivering the pending notifications, the deliveries keeping to the app and notifiers
+// of the time they were collected, as a reload may replace them meanwhile
 func (server *server) handleNotifications() error {
-	if len(server.cfg.NotificationCmd) > 0 {
-		todos, _ := server.app.findToBeNotifiedByDueBefore(time.Now())
-		for _, todo := range todos {
-			cmd := exec.Command(server.cfg.NotificationCmd, todo.Title, server.renderNotificationText(todo))
-			log.Debugf("Calling notification command: %s", cmd)
-			stdout, err := cmd.Output()
-			if err == nil {
-				log.Debugf("Result of executing notification command: %s", stdout)
-				err := server.app.markNotified(todo.Id)
-				if err != nil {
-					log.Errorf("Could not mark as notified: %s %s: %s", todo.Id, todo.Title, err)
-				}
+	server.reloadMu.Lock()
+	defer server.reloadMu.Unlock()
+	if len(server.notifiers) > 0 {
+		now := time.Now()
+		if server.calendar != nil && server.calendar.isQuiet(now) {
+			server.heldBack = true
+			return nil
+		}
+		pending := server.takeUndeliveredInternal(server.collectPendingNotificationsInternal(now))
+		if server.heldBack {
+			batched, escalated := server.splitEscalatedInternal(pending)
+			if len(batched) > 1 {
+				notifiers := server.notifiers
+				server.deliverAsyncInternal(batched, func() {
+					server.deliverBatchInternal(batched, notifiers)
+				})
+				pending = escalated
 			}
-			if err != nil {
-				exitErr, ok := err.(*exec.ExitError)
-				debugError := "{}"
-				if ok {
-					debugError = string(exitErr.Stderr)
+		}
+		for _, p := range pending {
+			p := p
+			server.deliverAsyncInternal([]pendingNotification{p}, func() {
+				if deliver(p.notifiers, p.event) {
+					server.markInternal(p)
 				}
-				log.Errorf("Error executing notification command: %s: Stdout: %s. DebugErr: %s.", err, stdout, debugError)
-			}
+			})
 		}
+		server.heldBack = false
 	}
 	return nil
 }
 
+// takeUndeliveredInternal leaves out the notifications still being delivered, taking the others as being delivered
+func (server *server) takeUndeliveredInternal(pending []pendingNotification) []pendingNotification {
+	server.deliveringMu.Lock()
+	defer server.deliveringMu.Unlock()
+	if server.delivering == nil {
+		server.delivering = make(map[string]bool)
+	}
+	undelivered := make([]pendingNotification, 0, len(pending))
+	for _, p := range pending {
+		if !server.delivering[p.key] {
+			server.delivering[p.key] = true
+			undelivered = append(undelivered, p)
+		}
+	}
+	return undelivered
+}
+
+// deliverAsyncInternal delivers in the background, so a notifier retrying a dead webhook does not hold up the tick
+func (server *server) deliverAsyncInternal(pending []pendingNotification, deliverFunc func()) {
+	server.deliveries.Add(1)
+	go func() {
+		defer server.deliveries.Done()
+		deliverFunc()
+		server.deliveringMu.Lock()
+		defer server.deliveringMu.Unlock()
+		for _, p := range pending {
+			delete(server.delivering, p.key)
+		}
+	}()
+}
+
+func (server *server) collectPendingNotificationsInternal(now time.Time) []pendingNotification {
+	pending := make([]pendingNotification, 0)
+	app := server.app
+	reminded, _ := app.findToBeRemindedAt(now)
+	for _, todo := range reminded {
+		before, ok := dueReminder(todo, now)
+		if !ok {
+			continue
+		}
+		todoId := todo.Id
+		pending = append(pending, pendingNotification{
+			key:       fmt.Sprintf("%s/%s/%s", EventTypeReminder, todoId, before),
+			event:     notificationEvent{Type: EventTypeReminder, Todo: todo, Text: server.renderReminderText(todo, before), Urgency: UrgencyNormal},
+			notifiers: server.notifiers,
+			mark: func() error {
+				return app.markReminded(todoId, before)
+			},
+		})
+	}
+	todos, _ := app.findToBeNotifiedByDueBefore(now)
+	for _, todo := range todos {
+		event := notificationEvent{Type: EventTypeDue, Todo: todo, Text: server.renderNotificationText(todo), Urgency: UrgencyNormal}
+		notifiers := server.notifiers
+		if isEscalated(todo.Notification) {
+			event.Urgency = UrgencyCritical
+			if len(server.escalationNotifiers) > 0 {
+				notifiers = server.escalationNotifiers
+			}
+		}
+		todoId := todo.Id
+		pending = append(pending, pendingNotification{
+			key:       fmt.Sprintf("%s/%s", EventTypeDue, todoId),
+			event:     event,
+			notifiers: notifiers,
+			mark: func() error {
+				return app.markNotified(todoId)
+			},
+		})
+	}
+	return pending
+}
+
+// splitEscalatedInternal takes the escalations out of the notifications held back, as they go to the escalation
+// notifiers on their own instead of being batched, as long as there are any
+func (server *server) splitEscalatedInternal(pending []pendingNotification) ([]pendingNotification, []pendingNotification) {
+	if len(server.escalationNotifiers) == 0 {
+		return pending, nil
+	}
+	batched := make([]pendingNotification, 0, len(pending))
+	escalated := make([]pendingNotification, 0)
+	for _, p := range pending {
+		if p.event.Urgency == UrgencyCritical {
+			escalated = append(escalated, p)
+		} else {
+			batched = append(batched, p)
+		}
+	}
+	return batched, escalated
+}
+
+// deliverBatchInternal sends the notifications held back during the quiet hours as one event
+func (server *server) deliverBatchInternal(pending []pendingNotification, notifiers []notifier) {
+	batch := notificationEvent{Type: EventTypeBatch, Todos: make([]todoModel, 0, len(pending)), Urgency: UrgencyNormal}
+	lines := make([]string, 0, len(pending))
+	for _, p := range pending {
+		batch.Todos = append(batch.Todos, p.event.Todo)
+		lines = append(lines, fmt.Sprintf("%s (%s)", p.event.Todo.Title, p.event.Todo.Due.Format(server.timeRenderLayout)))
+		if p.event.Urgency == UrgencyCritical {
+			batch.Urgency = UrgencyCritical
+		}
+	}
+	batch.Text = fmt.Sprintf("%d notifications held back during quiet hours:\n%s", len(pending), strings.Join(lines, "\n"))
+	if deliver(notifiers, batch) {
+		for _, p := range pending {
+			server.markInternal(p)
+		}
+	}
+}
+
+func (server *server) markInternal(p pendingNotification) {
+	err := p.mark()
+	if err != nil {
+		log.Errorf("Could not mark as notified: %s %s: %s", p.event.Todo.Id, p.event.Todo.Title, err)
+	}
+}
+
 func (server *server) renderNotificationText(todo todoModel) string {
 	return fmt.Sprintf("%s\n%s\n%s", todo.Title, todo.Due.Format(server.timeRenderLayout), todo.Details)
 }
 
+func (server *server) renderReminderText(todo todoModel, before string) string {
+	return fmt.Sprintf("Due in %s: %s", before, server.renderNotificationText(todo))
+}
+
 func (server *server) runSysTray() {
 	log.Debugf("Running in tray now")
 	systray.Run(server.onReady, server.onExit)
 }
 
 func (server *server) onReady() {
-	file, err := os.ReadFile(server.cfg.TrayIcon)
+	cfg := server.currentConfig()
+	file, err := os.ReadFile(cfg.TrayIcon)
 	if err != nil {
-		log.Errorf("Error reading icon from file '%s': %v\n", server.cfg.TrayIcon, err)
+		log.Errorf("Error reading icon from file '%s': %v\n", cfg.TrayIcon, err)
 	}
 	systray.SetIcon(file)
 	systray.SetTitle("Todo App")
@@ -145,19 +318,48 @@ func (server *server) onExit() {
 }
 
 func (server *server) runRestServer() {
-	restServer := newRestServer(server.app)
+	server.reloadMu.Lock()
+	cfg, app := server.cfg, server.app
+	server.reloadMu.Unlock()
+	report, err := parseReportOptions(cfg.ReportSections, cfg.ReportWindow)
+	if err != nil {
+		log.Fatalf("Invalid report config: %s\n", err)
+	}
+	restServer := newRestServer(app, server.events, report)
 	r := mux.NewRouter()
 	srv := &http.Server{
-		Addr: fmt.Sprintf("%s:%s", server.cfg.RestBaseHost, server.cfg.RestBasePort),
+		Addr: fmt.Sprintf("%s:%s", cfg.RestBaseHost, cfg.RestBasePort),
 		// Good practice to set timeouts to avoid Slowloris attacks.
-		WriteTimeout: time.Second * 15,
-		ReadTimeout:  time.Second * 15,
-		IdleTimeout:  time.Second * 60,
-		Handler:      r, // Pass our instance of gorilla/mux in.
+		// The write timeout is set per listener, as the event stream stays open.
+		ReadTimeout: time.Second * 15,
+		IdleTimeout: time.Second * 60,
+		Handler:     r, // Pass our instance of gorilla/mux in.
+		ConnContext: withConn,
+	}
+	tokens, err := loadRestTokens(cfg)
+	if err != nil {
+		log.Fatalf("Invalid rest token config: %s\n", err)
+	}
+	err = checkRestExposure(cfg.RestBaseHost, tokens)
+	if err != nil {
+		log.Fatalf("Refusing to run the rest server: %s\n", err)
+	}
+	if len(tokens) > 0 {
+		r.Use(tokenAuthMiddleware(tokens))
+	}
+	restServer.register(r, tokens)
+	useTls := len(cfg.RestTlsCert) > 0 || len(cfg.RestTlsKey) > 0
+	if useTls && (len(cfg.RestTlsCert) == 0 || len(cfg.RestTlsKey) == 0) {
+		log.Fatalf("Invalid rest tls config: both rest_tls_cert and rest_tls_key are needed\n")
+	}
+	if useTls {
+		log.Debugf("Running rest server with tls on Address '%s'\n", srv.Addr)
+		err = srv.ListenAndServeTLS(cfg.RestTlsCert, cfg.RestTlsKey)
+	} else {
+		log.Debugf("Running rest server on Address '%s'\n", srv.Addr)
+		err = srv.ListenAndServe()
 	}
-	for _, listener := range restServer.listeners {
-		r.HandleFunc(listener.path, listener.handler)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Errorf("Rest server stopped: %s\n", err)
 	}
-	log.Debugf("Running rest server on Address '%s'\n", srv.Addr)
-	srv.ListenAndServe()
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServerHandleNotifications_batchesAfterQuietHours(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "first", Due: time.Now().Add(-time.Hour)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "second", Due: time.Now().Add(-time.Minute)}) == nil)
+	alwaysQuiet := &calendar{quietOnDaysOff: true, workingDays: make(map[time.Weekday]bool)}
+	fake := &fakeNotifier{}
+	server := server{app: app, notifiers: []notifier{fake}, calendar: alwaysQuiet, timeRenderLayout: time.RFC1123}
+
+	assertTrue(t, server.handleNotifications() == nil)
+	server.deliveries.Wait()
+	assertTrue(t, fake.calls == 0)
+
+	server.calendar = &calendar{}
+	assertTrue(t, server.handleNotifications() == nil)
+	server.deliveries.Wait()
+	assertTrue(t, fake.calls == 1)
+	assertEquals(t, EventTypeBatch, fake.events[0].Type)
+	assertTrue(t, len(fake.events[0].Todos) == 2)
+
+	todos, _ := app.findToBeNotifiedByDueBefore(time.Now())
+	assertTrue(t, len(todos) == 0)
+}
+
+func TestServerHandleNotifications_sendsEscalationsHeldBackToTheEscalationNotifiers(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "first", Due: time.Now().Add(-time.Hour)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "second", Due: time.Now().Add(-time.Minute)}) == nil)
+	// notified twice, so repeated once already
+	assertTrue(t, app.repo.insertEntry(todo{Id: uuid.New(), Title: "nagging", Due: time.Now().Add(-2 * time.Hour), Notification: notification{Type: NotificationTypeRepeat,
+		Interval: time.Minute, EscalateAfter: 1, History: []time.Time{time.Now().Add(-time.Hour), time.Now().Add(-30 * time.Minute)}}}) == nil)
+	fake, escalation := &fakeNotifier{}, &fakeNotifier{}
+	server := server{app: app, notifiers: []notifier{fake}, escalationNotifiers: []notifier{escalation}, heldBack: true, timeRenderLayout: time.RFC1123}
+
+	assertTrue(t, server.handleNotifications() == nil)
+	server.deliveries.Wait()
+	assertTrue(t, fake.calls == 1)
+	assertEquals(t, EventTypeBatch, fake.events[0].Type)
+	assertTrue(t, len(fake.events[0].Todos) == 2)
+	assertEquals(t, UrgencyNormal, fake.events[0].Urgency)
+	assertTrue(t, escalation.calls == 1)
+	assertEquals(t, "nagging", escalation.events[0].Todo.Title)
+	assertEquals(t, UrgencyCritical, escalation.events[0].Urgency)
+}
+
+// blockingNotifier delivers once released, counting its calls
+type blockingNotifier struct {
+	calls   int32
+	release chan struct{}
+}
+
+func (n *blockingNotifier) name() string {
+	return "blocking"
+}
+
+func (n *blockingNotifier) notify(event notificationEvent) error {
+	atomic.AddInt32(&n.calls, 1)
+	<-n.release
+	return nil
+}
+
+func TestServerHandleNotifications_deliversWithoutHoldingUpTheTick(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "first", Due: time.Now().Add(-time.Hour)}) == nil)
+	blocking := &blockingNotifier{release: make(chan struct{})}
+	server := server{app: app, notifiers: []notifier{blocking}, timeRenderLayout: time.RFC1123}
+
+	assertTrue(t, server.handleNotifications() == nil)
+	assertTrue(t, server.handleNotifications() == nil)
+	close(blocking.release)
+	server.deliveries.Wait()
+
+	assertTrue(t, atomic.LoadInt32(&blocking.calls) == 1)
+	todos, _ := app.findToBeNotifiedByDueBefore(time.Now())
+	assertTrue(t, len(todos) == 0)
+}
+
+func TestServerReload_keepsDeliveriesUnderWayToThePreviousApp(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "first", Due: time.Now().Add(-time.Hour)}) == nil)
+	blocking := &blockingNotifier{release: make(chan struct{})}
+	server := server{app: app, notifiers: []notifier{blocking}, timeRenderLayout: time.RFC1123}
+
+	assertTrue(t, server.handleNotifications() == nil)
+	server.reload(config{Storage: StorageFs, TodoDir: t.TempDir(), Tick: time.Second})
+	assertTrue(t, server.app != app)
+	close(blocking.release)
+	server.deliveries.Wait()
+
+	todos, _ := app.findToBeNotifiedByDueBefore(time.Now())
+	assertTrue(t, len(todos) == 0)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// ParseTags strips '+tag', '@context' and 'project:name' tokens from the arguments.
+func ParseTags(arguments []string) ([]string, []string, string) {
+	remaining := make([]string, 0, len(arguments))
+	tags := make([]string, 0)
+	project := ""
+	for _, argument := range arguments {
+		if len(argument) > 1 && strings.HasPrefix(argument, "+") {
+			tags = appendTag(tags, argument[1:])
+		} else if len(argument) > 1 && strings.HasPrefix(argument, "@") {
+			tags = appendTag(tags, argument)
+		} else if len(argument) > len("project:") && strings.EqualFold("project:", argument[:len("project:")]) {
+			project = argument[len("project:"):]
+		} else {
+			remaining = append(remaining, argument)
+		}
+	}
+	return remaining, tags, project
+}
+
+func appendTag(tags []string, tag string) []string {
+	for _, present := range tags {
+		if strings.EqualFold(present, tag) {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
+
+func formatTags(tags []string, project string) string {
+	formatted := make([]string, 0, len(tags)+1)
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, "@") {
+			formatted = append(formatted, tag)
+		} else {
+			formatted = append(formatted, "+"+tag)
+		}
+	}
+	if len(project) > 0 {
+		formatted = append(formatted, "project:"+project)
+	}
+	return strings.Join(formatted, " ")
+}
+
+type todoFilter struct {
+	Tags    []string
+	Project string
+	// Ready hides todos blocked by another active todo
+	Ready bool
+	// Query is a filter expression evaluated by parseTodoQuery
+	Query string
+	// Location tells the days and times of the query, the local one if nil
+	Location *time.Location
+}
+
+// location returns the location the query is evaluated in
+func (f todoFilter) location() *time.Location {
+	if f.Location == nil {
+		return time.Local
+	}
+	return f.Location
+}
+
+func (f todoFilter) isEmpty() bool {
+	return len(f.Tags) == 0 && len(f.Project) == 0 && !f.Ready && len(strings.TrimSpace(f.Query)) == 0
+}
+
+func (f todoFilter) matches(tags []string, project string) bool {
+	if len(f.Project) > 0 && !strings.EqualFold(f.Project, project) {
+		return false
+	}
+	for _, wanted := range f.Tags {
+		found := false
+		for _, tag := range tags {
+			if strings.EqualFold(wanted, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
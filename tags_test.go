@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseTags_none(t *testing.T) {
+	arguments, tags, project := ParseTags([]string{"title", "tomorrow"})
+	assertEquals(t, "title tomorrow", join(arguments))
+	assertTrue(t, len(tags) == 0)
+	assertEquals(t, "", project)
+}
+
+func TestParseTags_tagsContextsAndProject(t *testing.T) {
+	arguments, tags, project := ParseTags([]string{"call", "+phone", "bob", "@office", "project:acme", "in", "2h"})
+	assertEquals(t, "call bob in 2h", join(arguments))
+	assertEquals(t, "phone @office", join(tags))
+	assertEquals(t, "acme", project)
+}
+
+func TestParseTags_duplicateTags(t *testing.T) {
+	_, tags, _ := ParseTags([]string{"title", "+work", "+Work"})
+	assertEquals(t, "work", join(tags))
+}
+
+func TestParseTags_bareSymbols(t *testing.T) {
+	arguments, tags, project := ParseTags([]string{"1", "+", "1", "@", "project:"})
+	assertEquals(t, "1 + 1 @ project:", join(arguments))
+	assertTrue(t, len(tags) == 0)
+	assertEquals(t, "", project)
+}
+
+func TestTodoFilter_matches(t *testing.T) {
+	filter := todoFilter{Tags: []string{"work", "@office"}, Project: "acme"}
+	assertTrue(t, filter.matches([]string{"@office", "Work", "urgent"}, "ACME"))
+	assertFalse(t, filter.matches([]string{"work"}, "acme"))
+	assertFalse(t, filter.matches([]string{"work", "@office"}, "other"))
+	assertTrue(t, todoFilter{}.matches(nil, ""))
+}
+
+func TestFormatTags(t *testing.T) {
+	assertEquals(t, "+work @office project:acme", formatTags([]string{"work", "@office"}, "acme"))
+}
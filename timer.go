@@ -2,12 +2,17 @@ package main
 
 import (
 	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type Timer struct {
 	calculationFunc MapTime
+	err             error
+	relative        bool
 }
 
 type MapTime func(time.Time) time.Time
@@ -16,53 +21,73 @@ func (t *Timer) isEmpty() bool {
 	return t.calculationFunc == nil
 }
 
+// Err returns an error if the arguments end in something looking like a due date that could not be understood.
+func (t *Timer) Err() error {
+	return t.err
+}
+
 func (t *Timer) Resolve(from time.Time) time.Time {
 	return t.calculationFunc(from)
 }
 
+// ResolveWithin resolves like Resolve, pushing due dates given relative to from, like '2h', 'in 3 days'
+// or 'tomorrow', onto the next working time of the calendar.
+func (t *Timer) ResolveWithin(from time.Time, calendar *calendar) time.Time {
+	resolved := t.Resolve(from)
+	if t.relative && calendar != nil {
+		return calendar.nextWorkingTime(resolved)
+	}
+	return resolved
+}
+
+const maxTimerArguments = 6
+
+var (
+	clockRegex     = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	ordinalRegex   = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th|\.)?$`)
+	ambiguousRegex = regexp.MustCompile(`^(\d{1,2}:\d{2}(am|pm)?|\d{1,2}(am|pm)|\d+(st|nd|rd|th)|\d{4}-\d{1,2}-\d{1,2})$`)
+)
+
+var monthNames = map[string]time.Month{
+	"jan": time.January, "january": time.January,
+	"feb": time.February, "february": time.February,
+	"mar": time.March, "march": time.March,
+	"apr": time.April, "april": time.April,
+	"may": time.May,
+	"jun": time.June, "june": time.June,
+	"jul": time.July, "july": time.July,
+	"aug": time.August, "august": time.August,
+	"sep": time.September, "sept": time.September, "september": time.September,
+	"oct": time.October, "october": time.October,
+	"nov": time.November, "november": time.November,
+	"dec": time.December, "december": time.December,
+}
+
+// ParseTimer strips a due date from the end of the arguments. Besides a duration and a timestamp like
+// '2006-01-02 15:04' it understands phrases like 'tomorrow', 'friday', 'next monday 9:00', 'today 17:00',
+// 'eod', 'eow', 'in 3 days', 'next month', '14:30' and '2nd of march', all resolved in the given location.
 func ParseTimer(arguments []string, location *time.Location) (string, *Timer) {
 	var calculationFunc MapTime = nil
+	var err error = nil
+	relative := false
 	titleArgs := arguments
-	if len(arguments) >= 2 {
-		// 1. Is arg duration?
-		parsedDueIn, err := time.ParseDuration(arguments[len(arguments)-1])
-		if err == nil {
-			calculationFunc = inDuration(parsedDueIn)
-			if strings.EqualFold("IN", strings.ToUpper(arguments[len(arguments)-2])) {
-				titleArgs = arguments[:len(arguments)-2]
-			} else {
-				titleArgs = arguments[:len(arguments)-1]
-			}
-		} else {
-			// 2. Is Arg tomorrow?
-			if strings.EqualFold("TOMORROW", strings.ToUpper(arguments[len(arguments)-1])) {
-				calculationFunc = tomorrow(location)
-				titleArgs = arguments[:len(arguments)-1]
-			} else {
-				// 3. Is arg time?
-				parsedTime, err := time.ParseInLocation("2006-01-02 15:04", arguments[len(arguments)-1], location)
-				if err == nil {
-					calculationFunc = atTime(parsedTime)
-					if strings.EqualFold("AT", strings.ToUpper(arguments[len(arguments)-2])) {
-						titleArgs = arguments[:len(arguments)-2]
-					} else {
-						titleArgs = arguments[:len(arguments)-1]
-					}
-				} else if len(arguments) >= 3 {
-					// 4. Is arg time with spaces?
-					parsedTime, err = time.ParseInLocation("2006-01-02 15:04", arguments[len(arguments)-2]+" "+arguments[len(arguments)-1], location)
-					if err == nil {
-						calculationFunc = atTime(parsedTime)
-						if strings.EqualFold("AT", strings.ToUpper(arguments[len(arguments)-3])) {
-							titleArgs = arguments[:len(arguments)-3]
-						} else {
-							titleArgs = arguments[:len(arguments)-2]
-						}
-					}
-				}
-			}
+	maxCount := len(arguments) - 1
+	if maxCount > maxTimerArguments {
+		maxCount = maxTimerArguments
+	}
+	for count := maxCount; count >= 1; count-- {
+		tokens := strings.Fields(strings.ToLower(strings.Join(arguments[len(arguments)-count:], " ")))
+		parsed := parseTimerExpression(tokens, location)
+		if parsed != nil {
+			calculationFunc = parsed
+			relative = isRelativeTimerExpression(tokens)
+			titleArgs = arguments[:len(arguments)-count]
+			break
 		}
 	}
+	if calculationFunc == nil && len(arguments) >= 2 {
+		err = detectAmbiguousTimer(arguments)
+	}
 	withoutDuration := ""
 	buffer := &bytes.Buffer{}
 	for i := 0; i < len(titleArgs); i++ {
@@ -73,7 +98,226 @@ func ParseTimer(arguments []string, location *time.Location) (string, *Timer) {
 		}
 	}
 	withoutDuration = buffer.String()
-	return withoutDuration, &Timer{calculationFunc: calculationFunc}
+	return withoutDuration, &Timer{calculationFunc: calculationFunc, err: err, relative: relative}
+}
+
+// isRelativeTimerExpression reports whether the tokens give a due date relative to now instead of a fixed time
+func isRelativeTimerExpression(tokens []string) bool {
+	if len(tokens) > 1 && (tokens[0] == "at" || tokens[0] == "on" || tokens[0] == "by") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 1 {
+		_, err := time.ParseDuration(tokens[0])
+		return err == nil || tokens[0] == "tomorrow"
+	}
+	return tokens[0] == "in"
+}
+
+func parseTimerExpression(tokens []string, location *time.Location) MapTime {
+	if len(tokens) == 0 {
+		return nil
+	}
+	if len(tokens) > 1 && (tokens[0] == "at" || tokens[0] == "on" || tokens[0] == "by") {
+		tokens = tokens[1:]
+	}
+	// 1. Is it a duration?
+	if len(tokens) == 1 || (len(tokens) == 2 && tokens[0] == "in") {
+		parsedDueIn, err := time.ParseDuration(tokens[len(tokens)-1])
+		if err == nil {
+			return inDuration(parsedDueIn)
+		}
+	}
+	// 2. Is it an amount of calendar units?
+	if len(tokens) == 3 && tokens[0] == "in" {
+		return inUnits(tokens[1], tokens[2])
+	}
+	// 3. Is it a timestamp?
+	parsedTime, err := time.ParseInLocation("2006-01-02 15:04", strings.Join(tokens, " "), location)
+	if err == nil {
+		return atTime(parsedTime)
+	}
+	// 4. Is it the end of the day or week?
+	if len(tokens) == 1 && tokens[0] == "eod" {
+		return endOfDay(location)
+	}
+	if len(tokens) == 1 && tokens[0] == "eow" {
+		return endOfWeek(location)
+	}
+	// 5. Is it a day, optionally followed by a time of day?
+	day, consumed := parseDay(tokens, location)
+	if day != nil {
+		rest := tokens[consumed:]
+		if len(rest) > 0 && rest[0] == "at" {
+			rest = rest[1:]
+		}
+		if len(rest) == 0 {
+			if tokens[0] == "tomorrow" {
+				return tomorrow(location)
+			}
+			return atDay(day, 11, 0)
+		}
+		if len(rest) == 1 {
+			hour, minute, ok := parseClock(rest[0])
+			if ok {
+				return atDay(day, hour, minute)
+			}
+		}
+		return nil
+	}
+	// 6. Is it a bare time of day?
+	if len(tokens) == 1 {
+		hour, minute, ok := parseClock(tokens[0])
+		if ok {
+			return nextClock(location, hour, minute)
+		}
+	}
+	return nil
+}
+
+// parseDay parses the leading tokens into a function returning the matching day at midnight.
+func parseDay(tokens []string, location *time.Location) (MapTime, int) {
+	switch tokens[0] {
+	case "today":
+		return func(relativeTo time.Time) time.Time {
+			return dateOf(relativeTo.In(location))
+		}, 1
+	case "tomorrow":
+		return func(relativeTo time.Time) time.Time {
+			return dateOf(relativeTo.In(location)).AddDate(0, 0, 1)
+		}, 1
+	case "next":
+		if len(tokens) < 2 {
+			return nil, 0
+		}
+		switch tokens[1] {
+		case "week":
+			return func(relativeTo time.Time) time.Time {
+				return startOfWeek(relativeTo.In(location)).AddDate(0, 0, 7)
+			}, 2
+		case "month":
+			return func(relativeTo time.Time) time.Time {
+				local := relativeTo.In(location)
+				return time.Date(local.Year(), local.Month()+1, 1, 0, 0, 0, 0, location)
+			}, 2
+		case "year":
+			return func(relativeTo time.Time) time.Time {
+				return time.Date(relativeTo.In(location).Year()+1, time.January, 1, 0, 0, 0, 0, location)
+			}, 2
+		}
+		weekday, ok := parseFullWeekday(tokens[1])
+		if ok {
+			return nextWeekday(location, weekday), 2
+		}
+		return nil, 0
+	}
+	weekday, ok := parseFullWeekday(tokens[0])
+	if ok {
+		return nextWeekday(location, weekday), 1
+	}
+	parsedDate, err := time.ParseInLocation("2006-01-02", tokens[0], location)
+	if err == nil {
+		return func(relativeTo time.Time) time.Time {
+			return parsedDate
+		}, 1
+	}
+	return parseDayOfMonth(tokens, location)
+}
+
+// parseDayOfMonth parses '2nd of march', '2 march', 'march 2nd' and the like into the next such day.
+func parseDayOfMonth(tokens []string, location *time.Location) (MapTime, int) {
+	var dayToken string
+	var month time.Month
+	consumed := 0
+	if m, ok := monthNames[tokens[0]]; ok && len(tokens) >= 2 {
+		month, dayToken, consumed = m, tokens[1], 2
+	} else if len(tokens) >= 3 && tokens[1] == "of" {
+		m, ok = monthNames[tokens[2]]
+		if !ok {
+			return nil, 0
+		}
+		month, dayToken, consumed = m, tokens[0], 3
+	} else if len(tokens) >= 2 {
+		m, ok = monthNames[tokens[1]]
+		if !ok {
+			return nil, 0
+		}
+		month, dayToken, consumed = m, tokens[0], 2
+	} else {
+		return nil, 0
+	}
+	match := ordinalRegex.FindStringSubmatch(dayToken)
+	if match == nil {
+		return nil, 0
+	}
+	day, _ := strconv.Atoi(match[1])
+	if day < 1 || day > 31 {
+		return nil, 0
+	}
+	if time.Date(2000, month, day, 0, 0, 0, 0, location).Month() != month {
+		return nil, 0
+	}
+	return func(relativeTo time.Time) time.Time {
+		local := relativeTo.In(location)
+		candidate := time.Date(local.Year(), month, day, 0, 0, 0, 0, location)
+		for candidate.Before(dateOf(local)) || candidate.Month() != month {
+			candidate = time.Date(candidate.Year()+1, month, day, 0, 0, 0, 0, location)
+		}
+		return candidate
+	}, consumed
+}
+
+// parseFullWeekday only accepts complete weekday names, as abbreviations like 'sun' or 'sat' are common title words
+func parseFullWeekday(token string) (time.Weekday, bool) {
+	weekday, ok := weekdayNames[token]
+	return weekday, ok && len(token) > 3
+}
+
+// parseClock parses a time of day like '14:30', '9am' or '5:30pm'
+func parseClock(token string) (int, int, bool) {
+	match := clockRegex.FindStringSubmatch(token)
+	if match == nil || (len(match[2]) == 0 && len(match[3]) == 0) {
+		return 0, 0, false
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute := 0
+	if len(match[2]) > 0 {
+		minute, _ = strconv.Atoi(match[2])
+	}
+	if len(match[3]) > 0 {
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour = hour % 12
+		if match[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+func detectAmbiguousTimer(arguments []string) error {
+	tokens := strings.Fields(strings.ToLower(strings.Join(arguments[1:], " ")))
+	if len(tokens) == 0 {
+		return nil
+	}
+	last := tokens[len(tokens)-1]
+	if ambiguousRegex.MatchString(last) {
+		return fmt.Errorf("due date '%s' not understood", last)
+	}
+	if len(tokens) >= 3 && tokens[len(tokens)-3] == "in" {
+		if _, err := strconv.Atoi(tokens[len(tokens)-2]); err == nil {
+			return fmt.Errorf("due date 'in %s %s' not understood, expecting minutes, hours, days, weeks, months or years", tokens[len(tokens)-2], last)
+		}
+	}
+	if len(tokens) >= 3 && tokens[len(tokens)-2] == "of" {
+		if _, ok := monthNames[last]; ok {
+			return fmt.Errorf("due date '%s of %s' not understood", tokens[len(tokens)-3], last)
+		}
+	}
+	return nil
 }
 
 func tomorrow(location *time.Location) MapTime {
@@ -96,3 +340,87 @@ func inDuration(in time.Duration) MapTime {
 		return relativeTo.Add(in)
 	}
 }
+
+func inUnits(amount string, unit string) MapTime {
+	count, err := strconv.Atoi(amount)
+	if amount == "a" || amount == "an" || amount == "one" {
+		count, err = 1, nil
+	}
+	if err != nil || count < 0 {
+		return nil
+	}
+	switch strings.TrimSuffix(unit, "s") {
+	case "min", "minute":
+		return inDuration(time.Duration(count) * time.Minute)
+	case "hour":
+		return inDuration(time.Duration(count) * time.Hour)
+	case "day":
+		return func(relativeTo time.Time) time.Time {
+			return relativeTo.AddDate(0, 0, count)
+		}
+	case "week":
+		return func(relativeTo time.Time) time.Time {
+			return relativeTo.AddDate(0, 0, 7*count)
+		}
+	case "month":
+		return func(relativeTo time.Time) time.Time {
+			return addMonthsClamped(relativeTo, count)
+		}
+	case "year":
+		return func(relativeTo time.Time) time.Time {
+			return addMonthsClamped(relativeTo, 12*count)
+		}
+	}
+	return nil
+}
+
+func atDay(day MapTime, hour int, minute int) MapTime {
+	return func(relativeTo time.Time) time.Time {
+		midnight := day(relativeTo)
+		return time.Date(midnight.Year(), midnight.Month(), midnight.Day(), hour, minute, 0, 0, midnight.Location())
+	}
+}
+
+func nextWeekday(location *time.Location, weekday time.Weekday) MapTime {
+	return func(relativeTo time.Time) time.Time {
+		local := relativeTo.In(location)
+		daysAhead := (int(weekday) - int(local.Weekday()) + 7) % 7
+		if daysAhead == 0 {
+			daysAhead = 7
+		}
+		return dateOf(local).AddDate(0, 0, daysAhead)
+	}
+}
+
+func endOfDay(location *time.Location) MapTime {
+	return func(relativeTo time.Time) time.Time {
+		today := dateOf(relativeTo.In(location))
+		endOfWorkingDay := today.Add(17 * time.Hour)
+		if !relativeTo.Before(endOfWorkingDay) {
+			return today.Add(23*time.Hour + 59*time.Minute)
+		}
+		return endOfWorkingDay
+	}
+}
+
+func endOfWeek(location *time.Location) MapTime {
+	return func(relativeTo time.Time) time.Time {
+		local := relativeTo.In(location)
+		friday := dateOf(local).AddDate(0, 0, (int(time.Friday)-int(local.Weekday())+7)%7)
+		if !local.Before(friday.Add(17 * time.Hour)) {
+			friday = friday.AddDate(0, 0, 7)
+		}
+		return friday.Add(17 * time.Hour)
+	}
+}
+
+func nextClock(location *time.Location, hour int, minute int) MapTime {
+	return func(relativeTo time.Time) time.Time {
+		local := relativeTo.In(location)
+		at := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, location)
+		if !at.After(relativeTo) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at
+	}
+}
@@ -67,3 +67,148 @@ func TestParseTimuration_atNothing(t *testing.T) {
 	assertEquals(t, "title at nothing", title)
 	assertTrue(t, timuration.isEmpty())
 }
+
+func TestParseTimuration_weekday(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	title, timuration := ParseTimer([]string{"title", "friday"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "title", title)
+	assertEquals(t, "2023-11-24T11:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_nextWeekdayWithTime(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	title, timuration := ParseTimer([]string{"title", "next", "monday", "9am"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "title", title)
+	assertEquals(t, "2023-11-20T09:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_sameWeekdayIsNextWeek(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	_, timuration := ParseTimer([]string{"title", "on", "saturday"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "2023-11-25T11:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_todayAtTime(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	title, timuration := ParseTimer([]string{"title", "today", "17:00"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "title", title)
+	assertEquals(t, "2023-11-18T17:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_tomorrowAtTime(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	_, timuration := ParseTimer([]string{"title", "tomorrow", "at", "8:15"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "2023-11-19T08:15:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_eod(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	_, timuration := ParseTimer([]string{"title", "eod"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "2023-11-18T17:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_eow(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-15T14:00:00+01:00")
+	_, timuration := ParseTimer([]string{"title", "eow"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "2023-11-17T17:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_eowOnWeekend(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	_, timuration := ParseTimer([]string{"title", "eow"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "2023-11-24T17:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_inDays(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	title, timuration := ParseTimer([]string{"title", "in", "3", "days"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "title", title)
+	assertEquals(t, "2023-11-21T14:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_inWeeks(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	_, timuration := ParseTimer([]string{"title", "in", "2", "weeks"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "2023-12-02T14:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_nextMonth(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	_, timuration := ParseTimer([]string{"title", "next", "month"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "2023-12-01T11:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_bareTimeLaterToday(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	_, timuration := ParseTimer([]string{"title", "14:30"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "2023-11-18T14:30:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_bareTimeTomorrow(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	_, timuration := ParseTimer([]string{"title", "at", "9:00"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "2023-11-19T09:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_dayOfMonth(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	title, timuration := ParseTimer([]string{"title", "2nd", "of", "march"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "title", title)
+	assertEquals(t, "2024-03-02T11:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_monthDayWithTime(t *testing.T) {
+	refTime, _ := time.Parse(time.RFC3339, "2023-11-18T14:00:00+01:00")
+	_, timuration := ParseTimer([]string{"title", "dec", "24th", "18:00"}, locationBerlin())
+	newTime := timuration.Resolve(refTime)
+	assertEquals(t, "2023-12-24T18:00:00+01:00", newTime.Format(time.RFC3339))
+}
+
+func TestParseTimuration_abbreviatedWeekdayStaysInTitle(t *testing.T) {
+	title, timuration := ParseTimer([]string{"walk", "in", "the", "sun"}, locationBerlin())
+	assertEquals(t, "walk in the sun", title)
+	assertTrue(t, timuration.isEmpty())
+	assertTrue(t, timuration.Err() == nil)
+}
+
+func TestParseTimuration_invalidTimeIsAmbiguous(t *testing.T) {
+	_, timuration := ParseTimer([]string{"title", "at", "25:00"}, locationBerlin())
+	assertTrue(t, timuration.isEmpty())
+	assertTrue(t, timuration.Err() != nil)
+}
+
+func TestParseTimuration_unknownUnitIsAmbiguous(t *testing.T) {
+	_, timuration := ParseTimer([]string{"title", "in", "3", "fortnights"}, locationBerlin())
+	assertTrue(t, timuration.isEmpty())
+	assertTrue(t, timuration.Err() != nil)
+}
+
+func TestParseTimuration_invalidDayOfMonthIsAmbiguous(t *testing.T) {
+	_, timuration := ParseTimer([]string{"title", "31st", "of", "february"}, locationBerlin())
+	assertTrue(t, timuration.isEmpty())
+	assertTrue(t, timuration.Err() != nil)
+}
+
+func TestParseTimuration_blankArguments(t *testing.T) {
+	_, timuration := ParseTimer([]string{"title", "", " "}, locationBerlin())
+	assertTrue(t, timuration.isEmpty())
+	assertTrue(t, timuration.Err() == nil)
+
+	_, timuration = ParseTimer([]string{"", ""}, locationBerlin())
+	assertTrue(t, timuration.isEmpty())
+	assertTrue(t, timuration.Err() == nil)
+}
@@ -46,9 +46,14 @@ func runServer(logFile *string, config config, runInTray *bool, runAsRestServer
 	}
 	log.Debugf("Start server with log level %s", log.GetLevel())
 
-	repo := newRepositoryMutex(newRepositoryFs(config))
-	app := &appLocal{repo: repo}
-	server := server{app: app, cfg: config, runWithTray: *runInTray, runAsRestServer: *runAsRestServer, timeRenderLayout: time.RFC1123}
+	repo := newRepository(config)
+	events := newEventBus()
+	app := &appLocal{repo: repo, events: events}
+	calendar, err := newCalendar(config)
+	if err != nil {
+		log.Fatalf("Invalid calendar config: %s\n", err)
+	}
+	server := server{app: app, cfg: config, calendar: calendar, events: events, notifiers: newNotifiers(config), escalationNotifiers: newEscalationNotifiers(config), runWithTray: *runInTray, runAsRestServer: *runAsRestServer, timeRenderLayout: time.RFC1123}
 
 	server.run()
 }
@@ -63,11 +68,14 @@ func runCli(config config, runAsRestClient *bool) {
 
 	var app app
 	if *runAsRestClient {
-		restClient := newRestClient(config.RemoteBaseUrl)
+		restClient, err := newRestClient(config)
+		if err != nil {
+			exitWithError("Error setting up the rest client: ", err)
+		}
 		log.Debugf("Running cli against remote server on BaseUrl '%s'\n", restClient.baseUrl)
 		app = newAppRemote(restClient)
 	} else {
-		repo := newRepositoryMutex(newRepositoryFs(config))
+		repo := newRepository(config)
 		app = &appLocal{repo: repo}
 	}
 	cli := cli{app, config, output{os.Stdout, os.Stderr}, time.RFC1123, time.Local}
@@ -87,34 +95,130 @@ func usage() {
 	_, _ = fmt.Fprintf(out, "  config\n")
 	_, _ = fmt.Fprintf(out, "\tprints the current configuration\n")
 	_, _ = fmt.Fprintf(out, "  add\n")
-	_, _ = fmt.Fprintf(out, "\tadds a new todo\n")
+	_, _ = fmt.Fprintf(out, "\tadds a new todo, optionally repeating by a rule like 'every 2 weeks on mon,thu until 2006-01-02 5 times',\n")
+	_, _ = fmt.Fprintf(out, "\ttagged by '+tag' or '@context', grouped by 'project:name' and prioritized by '!high', '!medium' or '!low'\n")
+	_, _ = fmt.Fprintf(out, "\tand reminded ahead of the due date by 'remind:1d,15m'. 'nag:30m' repeats the notification every 30 minutes\n")
+	_, _ = fmt.Fprintf(out, "\tuntil resolved, 'nag:30m,3' escalating after 3 repeats\n")
+	_, _ = fmt.Fprintf(out, "\t'--no-edit' skips the editor, as does a stdin not being a terminal, '-m <details>' sets the details\n")
+	_, _ = fmt.Fprintf(out, "\twithout the editor, and '-' reads the title and details from stdin, leaving all arguments to the\n")
+	_, _ = fmt.Fprintf(out, "\tdue date like in 'echo \"buy milk\" | todo add - tomorrow'.\n")
+	_, _ = fmt.Fprintf(out, "\t'--file <path>' creates one todo per line of the file, '-' reading from stdin\n")
 	_, _ = fmt.Fprintf(out, "  list\n")
-	_, _ = fmt.Fprintf(out, "\tlists all active todos\n")
+	_, _ = fmt.Fprintf(out, "\tlists all active todos, optionally filtered by '+tag', '@context' and 'project:name'\n")
+	_, _ = fmt.Fprintf(out, "\tand sorted by 'sort:due' (due, then priority) or 'sort:priority' (priority, then due).\n")
+	_, _ = fmt.Fprintf(out, "\t'--ready' hides todos blocked by another active todo\n")
+	_, _ = fmt.Fprintf(out, "\tFurther arguments form a query like 'due<2026-11-01 and title~deploy and not notified', comparing\n")
+	_, _ = fmt.Fprintf(out, "\ttitle, details, project, id, tag, priority, due or created by =, !=, <, <=, >, >=, ~ (contains) or !~,\n")
+	_, _ = fmt.Fprintf(out, "\tcombined by and, or, not and parentheses. notified, blocked, recurring and overdue stand alone\n")
+	_, _ = fmt.Fprintf(out, "  tui\n")
+	_, _ = fmt.Fprintf(out, "\topens the active todos full-screen, filtered and sorted like list, to move through them with\n")
+	_, _ = fmt.Fprintf(out, "\tthe arrow keys or j/k and resolve (r), snooze (s), delete (d), edit (e) or show (enter) the selected one\n")
+	_, _ = fmt.Fprintf(out, "  watch\n")
+	_, _ = fmt.Fprintf(out, "\tprints the todos created, updated, resolved, deleted and notified on the rest server as it happens,\n")
+	_, _ = fmt.Fprintf(out, "\tneeding the rest client mode\n")
 	_, _ = fmt.Fprintf(out, "  due\n")
-	_, _ = fmt.Fprintf(out, "\tlists all due todos\n")
+	_, _ = fmt.Fprintf(out, "\tlists all due todos, optionally filtered and sorted like list\n")
 	_, _ = fmt.Fprintf(out, "  show\n")
 	_, _ = fmt.Fprintf(out, "\tprints one todo in detail view\n")
+	_, _ = fmt.Fprintf(out, "  edit\n")
+	_, _ = fmt.Fprintf(out, "\topens an active todo in the editor to change its title, details, due date, notification and reminders\n")
 	_, _ = fmt.Fprintf(out, "  del\n")
 	_, _ = fmt.Fprintf(out, "\tdeletes an active todo\n")
 	_, _ = fmt.Fprintf(out, "  resolve\n")
 	_, _ = fmt.Fprintf(out, "\treolves an active todo\n")
 	_, _ = fmt.Fprintf(out, "  snooze\n")
 	_, _ = fmt.Fprintf(out, "\tsets a new due date for an active todo\n")
+	_, _ = fmt.Fprintf(out, "\n  del, resolve and snooze find the todo by its id or title, an exact title beating a partial one.\n")
+	_, _ = fmt.Fprintf(out, "  When several todos match, they are offered to choose from, or listed when stdin is not a terminal\n")
+	_, _ = fmt.Fprintf(out, "\n  Due dates are taken from the end of add and snooze, for example '2h', 'in 3 days', 'tomorrow',\n")
+	_, _ = fmt.Fprintf(out, "  'today 17:00', '14:30', 'friday', 'next monday 9am', 'eod', 'eow', 'next month', '2nd of march'\n")
+	_, _ = fmt.Fprintf(out, "  or '2006-01-02 15:04'\n\n")
+	_, _ = fmt.Fprintf(out, "  archive\n")
+	_, _ = fmt.Fprintf(out, "\tlists all resolved todos, optionally resolved between two dates in the format 2006-01-02\n")
+	_, _ = fmt.Fprintf(out, "  reopen\n")
+	_, _ = fmt.Fprintf(out, "\tmoves a resolved todo out of the archive, a resolved occurrence of a recurring todo replacing\n")
+	_, _ = fmt.Fprintf(out, "\tthe following occurrence created on resolving it\n")
+	_, _ = fmt.Fprintf(out, "  prio\n")
+	_, _ = fmt.Fprintf(out, "\tsets the priority of an active todo to high, medium, low or none\n")
+	_, _ = fmt.Fprintf(out, "  item\n")
+	_, _ = fmt.Fprintf(out, "\tadds an item to the checklist of an active todo, given by its id or a single word of its title,\n")
+	_, _ = fmt.Fprintf(out, "\tlike 'item 3f buy milk'. Lines like '- [ ] item' in the details of add and edit form a checklist, too\n")
+	_, _ = fmt.Fprintf(out, "  check\n")
+	_, _ = fmt.Fprintf(out, "\tchecks the checklist item at the given index of an active todo, like 'check 3f 2'\n")
+	_, _ = fmt.Fprintf(out, "  uncheck\n")
+	_, _ = fmt.Fprintf(out, "\tunchecks the checklist item at the given index of an active todo\n")
+	_, _ = fmt.Fprintf(out, "  block\n")
+	_, _ = fmt.Fprintf(out, "\tmarks an active todo as blocked by another one, like 'block deploy by review'. A blocked todo\n")
+	_, _ = fmt.Fprintf(out, "\tis not notified about until all of its blockers are resolved\n")
+	_, _ = fmt.Fprintf(out, "  unblock\n")
+	_, _ = fmt.Fprintf(out, "\tremoves a blocker from an active todo, like 'unblock deploy by review'\n")
+	_, _ = fmt.Fprintf(out, "  export\n")
+	_, _ = fmt.Fprintf(out, "\twrites the active todos, filtered like list, to stdout in the format given by '--format ics|todotxt',\n")
+	_, _ = fmt.Fprintf(out, "\tadding the resolved todos with '--archived'. ics writes each todo as VTODO or with '--events' as VEVENT,\n")
+	_, _ = fmt.Fprintf(out, "\ttodotxt always adds the resolved todos as completed lines\n")
+	_, _ = fmt.Fprintf(out, "  import\n")
+	_, _ = fmt.Fprintf(out, "\tadds the todos of a todo.txt file given by '--format todotxt <file>', '-' reading from stdin,\n")
+	_, _ = fmt.Fprintf(out, "\tcompleted ones right into the archive, skipping titles already present\n")
+	_, _ = fmt.Fprintf(out, "  report\n")
+	_, _ = fmt.Fprintf(out, "\twrites the overdue, this week's, upcoming and recently resolved todos, filtered like list, as\n")
+	_, _ = fmt.Fprintf(out, "\tmarkdown or with '--format html' as html page. '--sections overdue,week' picks the sections and\n")
+	_, _ = fmt.Fprintf(out, "\t'--window 7d' how far upcoming todos reach ahead and resolved ones back, defaulting to the config\n")
+	_, _ = fmt.Fprintf(out, "  backup\n")
+	_, _ = fmt.Fprintf(out, "\twrites all active and archived todos into a file or to stdout, as json or as tar.gz when the file\n")
+	_, _ = fmt.Fprintf(out, "\tends with .tar.gz or by '--format tar.gz'. As rest client it backs up the rest server, which needs\n")
+	_, _ = fmt.Fprintf(out, "\trest tokens configured for backup and restore\n")
+	_, _ = fmt.Fprintf(out, "  restore\n")
+	_, _ = fmt.Fprintf(out, "\tadds the todos of a backup file, '-' reading from stdin, keeping their ids and reporting those present\n")
+	_, _ = fmt.Fprintf(out, "\talready as conflicts. '--replace' deletes all todos, active and archived, before restoring\n")
+	_, _ = fmt.Fprintf(out, "  migrate\n")
+	_, _ = fmt.Fprintf(out, "\timports all todos of the todo directory, archive included, into the sqlite database\n")
+	_, _ = fmt.Fprintf(out, "\nOutput:\n")
+	_, _ = fmt.Fprintf(out, "  The commands list, due, show and archive accept '--output <format>' with the format being\n")
+	_, _ = fmt.Fprintf(out, "  text (default), plain (text without colors), json, csv or tsv\n")
 }
 
 func showConfig(config config) {
 	out := os.Stdout
 	_, _ = fmt.Fprintf(out, "Current config:\n")
 	_, _ = fmt.Fprintf(out, "  TodoDir=%s\n", config.TodoDir)
+	_, _ = fmt.Fprintf(out, "  Storage=%s\n", config.Storage)
+	_, _ = fmt.Fprintf(out, "  SqliteFile=%s\n", config.SqliteFile)
+	_, _ = fmt.Fprintf(out, "  WorkingDays=%s\n", config.WorkingDays)
+	_, _ = fmt.Fprintf(out, "  WorkingHours=%s\n", config.WorkingHours)
+	_, _ = fmt.Fprintf(out, "  ReportSections=%s\n", config.ReportSections)
+	_, _ = fmt.Fprintf(out, "  ReportWindow=%s\n", config.ReportWindow)
 	_, _ = fmt.Fprintf(out, "CLI config:\n")
 	_, _ = fmt.Fprintf(out, "  EditorCmd=%s\n", config.EditorCmd)
 	_, _ = fmt.Fprintf(out, "  RemoteBaseUrl=%s\n", config.RemoteBaseUrl)
+	_, _ = fmt.Fprintf(out, "  RemoteToken=%s\n", maskSecret(config.RemoteToken))
+	_, _ = fmt.Fprintf(out, "  RemoteCaFile=%s\n", config.RemoteCaFile)
+	_, _ = fmt.Fprintf(out, "  RemoteInsecure=%t\n", config.RemoteInsecure)
+	_, _ = fmt.Fprintf(out, "  SortMode=%s\n", config.SortMode)
+	_, _ = fmt.Fprintf(out, "  DueOnWorkingTime=%t\n", config.DueOnWorkingTime)
 	_, _ = fmt.Fprintf(out, "Server config:\n")
 	_, _ = fmt.Fprintf(out, "  Tick=%s\n", config.Tick)
 	_, _ = fmt.Fprintf(out, "  NotificationCmd=%s\n", config.NotificationCmd)
+	_, _ = fmt.Fprintf(out, "  WebhookUrls=%s\n", config.WebhookUrls)
+	_, _ = fmt.Fprintf(out, "  WebhookTimeout=%s\n", config.WebhookTimeout)
+	_, _ = fmt.Fprintf(out, "  WebhookRetries=%d\n", config.WebhookRetries)
+	_, _ = fmt.Fprintf(out, "  EscalationCmd=%s\n", config.EscalationCmd)
+	_, _ = fmt.Fprintf(out, "  EscalationWebhookUrls=%s\n", config.EscalationWebhookUrls)
+	_, _ = fmt.Fprintf(out, "  QuietHours=%s\n", config.QuietHours)
+	_, _ = fmt.Fprintf(out, "  QuietOnDaysOff=%t\n", config.QuietOnDaysOff)
 	_, _ = fmt.Fprintf(out, "  TrayIcon=%s\n", config.TrayIcon)
 	_, _ = fmt.Fprintf(out, "  RestBaseHost=%s\n", config.RestBaseHost)
 	_, _ = fmt.Fprintf(out, "  RestBasePort=%s\n", config.RestBasePort)
+	_, _ = fmt.Fprintf(out, "  RestTokens=%s\n", maskSecret(config.RestTokens))
+	_, _ = fmt.Fprintf(out, "  RestTokenFile=%s\n", config.RestTokenFile)
+	_, _ = fmt.Fprintf(out, "  RestTlsCert=%s\n", config.RestTlsCert)
+	_, _ = fmt.Fprintf(out, "  RestTlsKey=%s\n", config.RestTlsKey)
+}
+
+func maskSecret(secret string) string {
+	if len(secret) == 0 {
+		return ""
+	}
+	return "********"
 }
 
 func exitWithError(v ...any) {
@@ -123,13 +227,24 @@ func exitWithError(v ...any) {
 }
 
 type todo struct {
-	Title        string       `yaml:"title"`
-	Details      string       `yaml:"details"`
-	Due          time.Time    `yaml:"due,omitempty"`
-	Id           uuid.UUID    `yaml:"id"`
-	Notification notification `yaml:"notification"`
-	ResolvedAt   time.Time    `yaml:"resolvedAt"`
-	filepath     string
+	Title        string          `yaml:"title"`
+	Details      string          `yaml:"details"`
+	Due          time.Time       `yaml:"due,omitempty"`
+	Id           uuid.UUID       `yaml:"id"`
+	Notification notification    `yaml:"notification"`
+	ResolvedAt   time.Time       `yaml:"resolvedAt"`
+	Recurrence   *recurrence     `yaml:"recurrence,omitempty"`
+	Tags         []string        `yaml:"tags,omitempty"`
+	Project      string          `yaml:"project,omitempty"`
+	Priority     priority        `yaml:"priority,omitempty"`
+	Checklist    []checklistItem `yaml:"checklist,omitempty"`
+	// AutoResolve resolves the todo as soon as every checklist item is checked
+	AutoResolve bool        `yaml:"autoResolve,omitempty"`
+	BlockedBy   []uuid.UUID `yaml:"blockedBy,omitempty"`
+	// Revision counts the writes of the todo, telling whether it changed since it was read
+	Revision  int       `yaml:"revision,omitempty"`
+	CreatedAt time.Time `yaml:"createdAt,omitempty"`
+	filepath  string
 }
 
 func (t *todo) validate() error {
@@ -137,36 +252,93 @@ func (t *todo) validate() error {
 		t.Notification.Type = NotificationTypeNone
 	} else if strings.EqualFold(string(t.Notification.Type), string(NotificationTypeOnce)) {
 		t.Notification.Type = NotificationTypeOnce
+	} else if strings.EqualFold(string(t.Notification.Type), string(NotificationTypeRepeat)) {
+		t.Notification.Type = NotificationTypeRepeat
+		if t.Notification.Interval <= 0 {
+			return errors.New("notification type repeat needs an interval.")
+		}
 	} else if len(t.Notification.Type) > 0 {
 		return errors.New(fmt.Sprintf("notification type %s unknown.", t.Notification.Type))
 	}
+	if t.Revision == 0 {
+		// written before the revisions were counted
+		t.Revision = 1
+	}
+	if !t.Notification.NotifiedAt.IsZero() {
+		if len(t.Notification.History) == 0 {
+			t.Notification.History = []time.Time{t.Notification.NotifiedAt}
+		}
+		t.Notification.NotifiedAt = time.Time{}
+	}
+	parsedPriority, err := parsePriority(string(t.Priority))
+	if err != nil {
+		return err
+	}
+	t.Priority = parsedPriority
+	if t.Recurrence != nil {
+		return t.Recurrence.validate()
+	}
 	return nil
 }
 
 type notificationType string
 
 const (
-	NotificationTypeNone notificationType = "none"
-	NotificationTypeOnce notificationType = "once"
+	NotificationTypeNone   notificationType = "none"
+	NotificationTypeOnce   notificationType = "once"
+	NotificationTypeRepeat notificationType = "repeat"
 )
 
 type notification struct {
-	Type       notificationType `yaml:"type"`
-	NotifiedAt time.Time        `yaml:"notifiedAt,omitempty"`
+	Type notificationType `yaml:"type"`
+	// NotifiedAt is only read from todos written before the history was kept, validate moves it into the history
+	NotifiedAt    time.Time     `yaml:"notifiedAt,omitempty"`
+	History       []time.Time   `yaml:"history,omitempty"`
+	Interval      time.Duration `yaml:"interval,omitempty"`
+	EscalateAfter int           `yaml:"escalateAfter,omitempty"`
+	Reminders     []reminder    `yaml:"reminders,omitempty"`
+}
+
+// reset makes the notification and all reminders fire again, used when the due date changes
+func (n *notification) reset() {
+	n.History = nil
+	n.Reminders = resetReminders(n.Reminders)
+}
+
+func (n *notification) lastNotifiedAt() time.Time {
+	if len(n.History) == 0 {
+		return time.Time{}
+	}
+	return n.History[len(n.History)-1]
+}
+
+// isDue reports whether a todo with the given due date is to be notified at now
+func (n *notification) isDue(due time.Time, now time.Time) bool {
+	if !due.Before(now) {
+		return false
+	}
+	switch n.Type {
+	case NotificationTypeOnce:
+		return len(n.History) == 0
+	case NotificationTypeRepeat:
+		return len(n.History) == 0 || !now.Before(n.lastNotifiedAt().Add(n.Interval))
+	}
+	return false
 }
 
 type todoModels struct {
 	items []todoModel
+	mode  sortMode
 }
 
-func sorted(items []todoModel) []todoModel {
-	sortable := list(items)
+func sorted(items []todoModel, mode sortMode) []todoModel {
+	sortable := list(items, mode)
 	sort.Sort(sortable)
 	return sortable.items
 }
 
-func list(items []todoModel) *todoModels {
-	return &todoModels{items}
+func list(items []todoModel, mode sortMode) *todoModels {
+	return &todoModels{items, mode}
 }
 
 func (t *todoModels) Len() int {
@@ -174,7 +346,14 @@ func (t *todoModels) Len() int {
 }
 
 func (t *todoModels) Less(i, j int) bool {
-	return !t.items[i].Due.After(t.items[j].Due)
+	byDue := !t.items[i].Due.After(t.items[j].Due)
+	if t.items[i].Due.Equal(t.items[j].Due) || t.mode == SortModePriority {
+		rankI, rankJ := priorityRank(t.items[i].Priority), priorityRank(t.items[j].Priority)
+		if rankI != rankJ {
+			return rankI < rankJ
+		}
+	}
+	return byDue
 }
 
 func (t *todoModels) Swap(i, j int) {
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const todoTxtDateLayout = "2006-01-02"
+
+// todoTxtDueHour is the hour a todo imported with a due date only is due at, the end of a working day
+const todoTxtDueHour = 17
+
+var todoTxtPriorities = map[priority]string{PriorityHigh: "A", PriorityMedium: "B", PriorityLow: "C"}
+
+// writeTodoTxt writes one line in the todo.txt format per todo, resolved todos as completed
+func writeTodoTxt(out io.Writer, entries []todoModel) error {
+	for _, entry := range entries {
+		_, err := io.WriteString(out, formatTodoTxtLine(entry)+"\n")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatTodoTxtLine formats the todo like '(A) 2023-05-01 Title +project +tag @context due:2023-05-03' or,
+// when resolved, like 'x 2023-05-02 2023-05-01 Title +project due:2023-05-03 pri:A'. The project comes first of
+// the '+' tokens, as reading the line takes the first one as project.
+func formatTodoTxtLine(entry todoModel) string {
+	tokens := make([]string, 0)
+	todoTxtPriority, hasPriority := todoTxtPriorities[priority(entry.Priority)]
+	if !entry.ResolvedAt.IsZero() {
+		tokens = append(tokens, "x", entry.ResolvedAt.Format(todoTxtDateLayout))
+	} else if hasPriority {
+		tokens = append(tokens, "("+todoTxtPriority+")")
+	}
+	if !entry.CreatedAt.IsZero() {
+		tokens = append(tokens, entry.CreatedAt.Format(todoTxtDateLayout))
+	} else if !entry.ResolvedAt.IsZero() {
+		// a completion date needs a creation date to follow, not to be mistaken for one
+		tokens = append(tokens, entry.ResolvedAt.Format(todoTxtDateLayout))
+	}
+	tokens = append(tokens, strings.Join(strings.Fields(entry.Title), " "))
+	if len(entry.Project) > 0 {
+		tokens = append(tokens, "+"+entry.Project)
+	}
+	for _, tag := range entry.Tags {
+		if strings.HasPrefix(tag, "@") {
+			tokens = append(tokens, tag)
+		} else {
+			tokens = append(tokens, "+"+tag)
+		}
+	}
+	if !entry.Due.IsZero() {
+		tokens = append(tokens, "due:"+entry.Due.Format(todoTxtDateLayout))
+	}
+	if !entry.ResolvedAt.IsZero() && hasPriority {
+		tokens = append(tokens, "pri:"+todoTxtPriority)
+	}
+	return strings.Join(tokens, " ")
+}
+
+// readTodoTxt parses every line of the todo.txt content, skipping blank ones
+func readTodoTxt(in io.Reader, location *time.Location, now time.Time) ([]todoModel, error) {
+	entries := make([]todoModel, 0)
+	scanner := bufio.NewScanner(in)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		entry, err := parseTodoTxtLine(line, location, now)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// parseTodoTxtLine reads a todo.txt line, the first '+project' becoming the project and further ones tags. A todo
+// without due date is due now without notification, as todo.txt has no means to tell when to be notified.
+func parseTodoTxtLine(line string, location *time.Location, now time.Time) (todoModel, error) {
+	entry := todoModel{}
+	tokens := strings.Fields(line)
+	if len(tokens) > 0 && tokens[0] == "x" {
+		tokens = tokens[1:]
+		entry.ResolvedAt = now
+		if date, ok := parseTodoTxtDate(tokens, location); ok {
+			entry.ResolvedAt = date
+			tokens = tokens[1:]
+		}
+	}
+	if len(tokens) > 0 && len(tokens[0]) == 3 && tokens[0][0] == '(' && tokens[0][1] >= 'A' && tokens[0][1] <= 'Z' && tokens[0][2] == ')' {
+		entry.Priority = string(parseTodoTxtPriority(tokens[0][1:2]))
+		tokens = tokens[1:]
+	}
+	if date, ok := parseTodoTxtDate(tokens, location); ok {
+		entry.CreatedAt = date
+		tokens = tokens[1:]
+	}
+	title := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if len(token) > 1 && strings.HasPrefix(token, "+") {
+			if len(entry.Project) == 0 {
+				entry.Project = token[1:]
+			} else {
+				entry.Tags = appendTag(entry.Tags, token[1:])
+			}
+		} else if len(token) > 1 && strings.HasPrefix(token, "@") {
+			entry.Tags = appendTag(entry.Tags, token)
+		} else if strings.HasPrefix(token, "due:") {
+			due, err := time.ParseInLocation(todoTxtDateLayout, token[len("due:"):], location)
+			if err != nil {
+				return entry, fmt.Errorf("invalid due date %s", token)
+			}
+			entry.Due = due.Add(todoTxtDueHour * time.Hour)
+		} else if strings.HasPrefix(token, "pri:") {
+			entry.Priority = string(parseTodoTxtPriority(token[len("pri:"):]))
+		} else {
+			title = append(title, token)
+		}
+	}
+	entry.Title = strings.Join(title, " ")
+	if len(entry.Title) == 0 {
+		return entry, fmt.Errorf("title missing in '%s'", line)
+	}
+	if entry.Due.IsZero() {
+		entry.Due = now
+		entry.Notification.Type = string(NotificationTypeNone)
+	}
+	return entry, nil
+}
+
+func parseTodoTxtDate(tokens []string, location *time.Location) (time.Time, bool) {
+	if len(tokens) == 0 {
+		return time.Time{}, false
+	}
+	date, err := time.ParseInLocation(todoTxtDateLayout, tokens[0], location)
+	return date, err == nil
+}
+
+// parseTodoTxtPriority maps A to high, B to medium and every lower letter to low
+func parseTodoTxtPriority(letter string) priority {
+	switch strings.ToUpper(letter) {
+	case "A":
+		return PriorityHigh
+	case "B":
+		return PriorityMedium
+	case "":
+		return PriorityNone
+	}
+	return PriorityLow
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatTodoTxtLine(t *testing.T) {
+	entry := todoModel{Title: "Pay rent", Priority: string(PriorityHigh), Project: "flat", Tags: []string{"money", "@home"},
+		CreatedAt: time.Date(2023, 5, 1, 8, 0, 0, 0, time.UTC), Due: time.Date(2023, 5, 3, 10, 0, 0, 0, time.UTC)}
+	assertEquals(t, "(A) 2023-05-01 Pay rent +flat +money @home due:2023-05-03", formatTodoTxtLine(entry))
+
+	entry.ResolvedAt = time.Date(2023, 5, 2, 9, 0, 0, 0, time.UTC)
+	assertEquals(t, "x 2023-05-02 2023-05-01 Pay rent +flat +money @home due:2023-05-03 pri:A", formatTodoTxtLine(entry))
+}
+
+func TestParseTodoTxtLine(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	entry, err := parseTodoTxtLine("(B) 2023-05-01 Call mom +family +phone @home due:2023-05-03", time.UTC, now)
+	assertTrue(t, err == nil)
+	assertEquals(t, "Call mom", entry.Title)
+	assertEquals(t, string(PriorityMedium), entry.Priority)
+	assertEquals(t, "family", entry.Project)
+	assertEquals(t, "phone @home", strings.Join(entry.Tags, " "))
+	assertTrue(t, entry.CreatedAt.Equal(time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)))
+	assertTrue(t, entry.Due.Equal(time.Date(2023, 5, 3, 17, 0, 0, 0, time.UTC)))
+	assertTrue(t, entry.ResolvedAt.IsZero())
+}
+
+func TestParseTodoTxtLine_completedWithoutDue(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	entry, err := parseTodoTxtLine("x 2023-05-02 2023-05-01 Water plants pri:D", time.UTC, now)
+	assertTrue(t, err == nil)
+	assertEquals(t, "Water plants", entry.Title)
+	assertEquals(t, string(PriorityLow), entry.Priority)
+	assertTrue(t, entry.ResolvedAt.Equal(time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC)))
+	assertTrue(t, entry.Due.Equal(now))
+	assertEquals(t, string(NotificationTypeNone), entry.Notification.Type)
+
+	_, err = parseTodoTxtLine("(A) due:tomorrow", time.UTC, now)
+	assertTrue(t, err != nil)
+}
+
+func TestTodoTxt_roundTrip(t *testing.T) {
+	entries := []todoModel{
+		{Title: "first", Project: "work", Tags: []string{"@office"}, Due: time.Date(2023, 5, 3, 17, 0, 0, 0, time.UTC), CreatedAt: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{Title: "second", Due: time.Date(2023, 5, 4, 17, 0, 0, 0, time.UTC), CreatedAt: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), ResolvedAt: time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	out := &bytes.Buffer{}
+	assertTrue(t, writeTodoTxt(out, entries) == nil)
+	read, err := readTodoTxt(strings.NewReader(out.String()), time.UTC, time.Now())
+	assertTrue(t, err == nil)
+	assertTrue(t, len(read) == 2)
+	for i := range entries {
+		assertEquals(t, formatTodoTxtLine(entries[i]), formatTodoTxtLine(read[i]))
+	}
+}
+
+func TestAppLocal_addResolvedIntoArchive(t *testing.T) {
+	app := newTestApp(t)
+	resolvedAt := time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC)
+	assertTrue(t, app.add(todoModel{Title: "done", Due: time.Now(), ResolvedAt: resolvedAt}) == nil)
+	active, _ := app.findAll(todoFilter{})
+	assertTrue(t, len(active) == 0)
+	archived, _ := app.findInArchive("done")
+	assertTrue(t, archived != nil)
+	assertTrue(t, archived.ResolvedAt.Equal(resolvedAt))
+	assertFalse(t, archived.CreatedAt.IsZero())
+}
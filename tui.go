@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tuiRefreshInterval = 5 * time.Second
+
+var ansiEscapeRegex = regexp.MustCompile("\x1b\\[[0-9;?]*[a-zA-Z]")
+
+// tuiPrompt reads a line of input in the status line, calling submit on enter
+type tuiPrompt struct {
+	label  string
+	input  string
+	submit func(input string)
+}
+
+// tui is the full-screen mode of the cli, listing the active todos live and acting on the selected one
+type tui struct {
+	cli      *cli
+	filter   todoFilter
+	mode     sortMode
+	entries  []todoModel
+	idMap    ShortIdMap
+	selected int
+	offset   int
+	details  bool
+	status   string
+	prompt   *tuiPrompt
+	width    int
+	height   int
+	quit     bool
+	// detailsLines caches the details of the todo detailsId until the next refresh, as they look up its blockers
+	detailsId    uuid.UUID
+	detailsLines []string
+	// suspend runs the given func with the terminal restored, like for the editor
+	suspend func(func())
+}
+
+func newTui(cli *cli, filter todoFilter, mode sortMode) *tui {
+	return &tui{cli: cli, filter: filter, mode: mode, width: 80, height: 24, suspend: func(f func()) { f() }}
+}
+
+func (cli *cli) tui(arguments []string) {
+	filter, mode, ok := cli.parseListArguments(arguments)
+	if !ok {
+		return
+	}
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		cli.Errorf("The tui needs a terminal\n")
+		return
+	}
+	err := newTui(cli, filter, mode).run(os.Stdin, os.Stdout)
+	if err != nil {
+		cli.Errorf("Could not run the tui: %s\n", err)
+	}
+}
+
+func (t *tui) run(in *os.File, out io.Writer) error {
+	state, err := stty(in, "-g")
+	if err != nil {
+		return err
+	}
+	enter := func() error {
+		// reads return after a second without input, giving the loop the chance to refresh
+		_, err := stty(in, "raw", "-echo", "min", "0", "time", "10")
+		fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+		return err
+	}
+	leave := func() {
+		fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+		_, _ = stty(in, strings.TrimSpace(state))
+	}
+	if err = enter(); err != nil {
+		leave()
+		return err
+	}
+	defer leave()
+	t.suspend = func(f func()) {
+		leave()
+		f()
+		_ = enter()
+	}
+
+	resized := make(chan os.Signal, 1)
+	notifyResize(resized)
+	defer signal.Stop(resized)
+
+	t.width, t.height = terminalSize(in)
+	t.refresh()
+	lastRefresh := time.Now()
+	buffer := make([]byte, 32)
+	dirty := true
+	for !t.quit {
+		select {
+		case <-resized:
+			t.width, t.height = terminalSize(in)
+			dirty = true
+		default:
+		}
+		if dirty {
+			fmt.Fprint(out, t.render())
+			dirty = false
+		}
+		n, err := in.Read(buffer)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if n == 0 {
+			if time.Since(lastRefresh) >= tuiRefreshInterval && t.prompt == nil {
+				t.refresh()
+				lastRefresh = time.Now()
+				dirty = true
+			}
+			continue
+		}
+		for _, key := range parseKeys(buffer[:n]) {
+			t.handleKey(key)
+		}
+		dirty = true
+	}
+	return nil
+}
+
+// parseKeys splits the bytes read from the terminal into keys, naming arrow keys 'up', 'down', 'left' and 'right'
+func parseKeys(input []byte) []string {
+	keys := make([]string, 0, len(input))
+	for i := 0; i < len(input); i++ {
+		switch {
+		case input[i] == 0x1b && i+2 < len(input) && input[i+1] == '[':
+			switch input[i+2] {
+			case 'A':
+				keys = append(keys, "up")
+			case 'B':
+				keys = append(keys, "down")
+			case 'C':
+				keys = append(keys, "right")
+			case 'D':
+				keys = append(keys, "left")
+			}
+			i += 2
+		case input[i] == 0x1b:
+			keys = append(keys, "esc")
+		case input[i] == '\r' || input[i] == '\n':
+			keys = append(keys, "enter")
+		case input[i] == 0x7f || input[i] == 0x08:
+			keys = append(keys, "backspace")
+		case input[i] == 0x03:
+			keys = append(keys, "ctrl-c")
+		default:
+			r := []rune(string(input[i:]))[0]
+			keys = append(keys, string(r))
+			i += len(string(r)) - 1
+		}
+	}
+	return keys
+}
+
+func (t *tui) refresh() {
+	selectedId := uuid.Nil
+	if entry := t.selectedEntry(); entry != nil {
+		selectedId = entry.Id
+	}
+	entries, idMap := t.cli.app.findAll(t.filter)
+	t.entries, t.idMap = sorted(entries, t.mode), idMap
+	t.detailsId = uuid.Nil
+	for i, entry := range t.entries {
+		if entry.Id == selectedId {
+			t.selected = i
+		}
+	}
+	if t.selected >= len(t.entries) {
+		t.selected = len(t.entries) - 1
+	}
+	if t.selected < 0 {
+		t.selected = 0
+	}
+}
+
+func (t *tui) selectedEntry() *todoModel {
+	if t.selected < 0 || t.selected >= len(t.entries) {
+		return nil
+	}
+	return &t.entries[t.selected]
+}
+
+func (t *tui) handleKey(key string) {
+	if t.prompt != nil {
+		t.handlePromptKey(key)
+		return
+	}
+	t.status = ""
+	entry := t.selectedEntry()
+	switch key {
+	case "q", "ctrl-c":
+		t.quit = true
+	case "up", "k":
+		if t.selected > 0 {
+			t.selected--
+		}
+	case "down", "j":
+		if t.selected < len(t.entries)-1 {
+			t.selected++
+		}
+	case "g":
+		t.refresh()
+	case "enter", " ":
+		t.details = !t.details
+	case "right":
+		t.details = true
+	case "left":
+		t.details = false
+	case "r":
+		if entry != nil {
+			t.resolve(*entry)
+		}
+	case "s":
+		if entry != nil {
+			target := *entry
+			t.prompt = &tuiPrompt{label: fmt.Sprintf("Snooze %s until: ", target.Title), submit: func(input string) { t.snooze(target, input) }}
+		}
+	case "d":
+		if entry != nil {
+			target := *entry
+			t.prompt = &tuiPrompt{label: fmt.Sprintf("Delete %s? [y/N] ", target.Title), submit: func(input string) {
+				if strings.EqualFold("y", input) || strings.EqualFold("yes", input) {
+					t.delete(target)
+				}
+			}}
+		}
+	case "e":
+		if entry != nil {
+			t.edit(*entry)
+		}
+	}
+}
+
+func (t *tui) handlePromptKey(key string) {
+	switch key {
+	case "esc", "ctrl-c":
+		t.prompt = nil
+	case "enter":
+		prompt := t.prompt
+		t.prompt = nil
+		prompt.submit(strings.TrimSpace(prompt.input))
+	case "backspace":
+		input := []rune(t.prompt.input)
+		if len(input) > 0 {
+			t.prompt.input = string(input[:len(input)-1])
+		}
+	default:
+		if len([]rune(key)) == 1 {
+			t.prompt.input += key
+		}
+	}
+}
+
+func (t *tui) resolve(entry todoModel) {
+	err := t.cli.app.resolve(entry.Id)
+	if err != nil {
+		t.status = fmt.Sprintf("Could not resolve %s: %s", entry.Title, err)
+	} else {
+		t.status = fmt.Sprintf("Resolved %s", entry.Title)
+	}
+	t.refresh()
+}
+
+func (t *tui) snooze(entry todoModel, input string) {
+	newDue := time.Now().Add(1 * time.Hour)
+	if len(input) > 0 {
+		// like for snooze, the due date follows the todo searched for
+		searchFor, timer := ParseTimer(append([]string{entry.Id.String()}, strings.Fields(input)...), t.cli.location)
+		if timer.Err() != nil || timer.isEmpty() || searchFor != entry.Id.String() {
+			t.status = fmt.Sprintf("Could not snooze %s: due date %s not understood", entry.Title, input)
+			return
+		}
+		newDue = t.cli.resolveDue(timer)
+	}
+	err := t.cli.app.setNewDue(entry.Id, newDue)
+	if err != nil {
+		t.status = fmt.Sprintf("Could not snooze %s: %s", entry.Title, err)
+	} else {
+		t.status = fmt.Sprintf("Snoozed %s until %s", entry.Title, t.cli.format(newDue))
+	}
+	t.refresh()
+}
+
+func (t *tui) delete(entry todoModel) {
+	err := t.cli.app.delete(entry.Id)
+	if err != nil {
+		t.status = fmt.Sprintf("Could not delete %s: %s", entry.Title, err)
+	} else {
+		t.status = fmt.Sprintf("Deleted %s", entry.Title)
+	}
+	t.refresh()
+}
+
+func (t *tui) edit(entry todoModel) {
+	buffer := &bytes.Buffer{}
+	editCli := *t.cli
+	editCli.output = output{buffer, buffer}
+	t.suspend(func() {
+		editCli.edit([]string{entry.Id.String()})
+	})
+	t.status = strings.TrimSpace(ansiEscapeRegex.ReplaceAllString(buffer.String(), ""))
+	t.refresh()
+}
+
+// render draws the whole screen, the list on the left and the details of the selected todo on the right
+func (t *tui) render() string {
+	listHeight := t.height - 2
+	if listHeight < 1 {
+		listHeight = 1
+	}
+	if t.selected < t.offset {
+		t.offset = t.selected
+	}
+	if t.selected >= t.offset+listHeight {
+		t.offset = t.selected - listHeight + 1
+	}
+	listWidth := t.width
+	var details []string
+	entry := t.selectedEntry()
+	if t.details && entry != nil && t.width >= 40 {
+		listWidth = t.width / 2
+		if t.detailsId != entry.Id {
+			formatted := t.cli.formatDetails(entry, t.idMap[entry.Id.String()])
+			t.detailsId, t.detailsLines = entry.Id, strings.Split(ansiEscapeRegex.ReplaceAllString(formatted, ""), "\n")
+		}
+		details = t.detailsLines
+	}
+
+	builder := &strings.Builder{}
+	builder.WriteString("\x1b[H\x1b[2J")
+	header := fmt.Sprintf("%d todos  j/k move  enter details  r resolve  s snooze  d delete  e edit  g refresh  q quit", len(t.entries))
+	builder.WriteString("\x1b[1m" + fitWidth(header, t.width) + "\x1b[0m\r\n")
+	for row := 0; row < listHeight; row++ {
+		line := ""
+		index := t.offset + row
+		if index < len(t.entries) {
+			line = fitWidth(t.formatEntry(t.entries[index]), listWidth)
+			if index == t.selected {
+				line = "\x1b[7m" + line + "\x1b[0m"
+			}
+		} else {
+			line = fitWidth("", listWidth)
+		}
+		if details != nil {
+			detail := ""
+			if row < len(details) {
+				detail = details[row]
+			}
+			line += "│ " + fitWidth(detail, t.width-listWidth-2)
+		}
+		builder.WriteString(strings.TrimRight(line, " ") + "\r\n")
+	}
+	if t.prompt != nil {
+		builder.WriteString(fitWidth(t.prompt.label+t.prompt.input, t.width))
+	} else {
+		builder.WriteString(fitWidth(t.status, t.width))
+	}
+	return builder.String()
+}
+
+func (t *tui) formatEntry(entry todoModel) string {
+	title := entry.Title
+	if len(entry.Priority) > 0 {
+		title = "!" + entry.Priority + " " + title
+	}
+	if len(entry.Checklist) > 0 {
+		done, total := checklistProgress(entry.Checklist)
+		title += fmt.Sprintf(" [%d/%d]", done, total)
+	}
+	if len(entry.Tags) > 0 || len(entry.Project) > 0 {
+		title += " " + formatTags(entry.Tags, entry.Project)
+	}
+	if entry.Blocked {
+		title += " (blocked)"
+	}
+	return fmt.Sprintf("[%s] %s %s", t.idMap[entry.Id.String()], title, t.cli.formatRelativeTo(entry.Due, time.Now()))
+}
+
+// fitWidth cuts or pads the text to exactly width characters
+func fitWidth(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(strings.ReplaceAll(text, "\t", " "))
+	if len(runes) > width {
+		if width == 1 {
+			return "…"
+		}
+		return string(runes[:width-1]) + "…"
+	}
+	return string(runes) + strings.Repeat(" ", width-len(runes))
+}
+
+func stty(terminal *os.File, arguments ...string) (string, error) {
+	cmd := exec.Command("stty", arguments...)
+	cmd.Stdin = terminal
+	result, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not set up the terminal by stty: %w", err)
+	}
+	return string(result), nil
+}
+
+func terminalSize(terminal *os.File) (int, int) {
+	size, err := stty(terminal, "size")
+	if err == nil {
+		fields := strings.Fields(size)
+		if len(fields) == 2 {
+			rows, rowsErr := strconv.Atoi(fields[0])
+			columns, columnsErr := strconv.Atoi(fields[1])
+			if rowsErr == nil && columnsErr == nil && rows > 0 && columns > 0 {
+				return columns, rows
+			}
+		}
+	}
+	return 80, 24
+}
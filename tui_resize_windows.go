@@ -0,0 +1,9 @@
+package main
+
+import (
+	"os"
+)
+
+// notifyResize does nothing, as windows has no signal telling the terminal changed its size
+func notifyResize(resized chan<- os.Signal) {
+}
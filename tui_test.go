@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("j\x1b[Ak\r\x7f\x1bä"))
+	assertEquals(t, "j up k enter backspace esc ä", strings.Join(keys, " "))
+}
+
+func TestFitWidth(t *testing.T) {
+	assertEquals(t, "abc  ", fitWidth("abc", 5))
+	assertEquals(t, "abcd…", fitWidth("abcdefgh", 5))
+	assertEquals(t, "", fitWidth("abc", 0))
+}
+
+func TestTui_actsOnSelectedTodo(t *testing.T) {
+	app := newTestApp(t)
+	assertTrue(t, app.add(todoModel{Title: "first", Due: time.Now().Add(time.Hour)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "second", Due: time.Now().Add(2 * time.Hour)}) == nil)
+	assertTrue(t, app.add(todoModel{Title: "third", Due: time.Now().Add(3 * time.Hour)}) == nil)
+	cli := &cli{app: app, timeRenderLayout: time.RFC1123, location: time.UTC}
+	tui := newTui(cli, todoFilter{}, SortModeDue)
+	tui.refresh()
+	assertTrue(t, strings.Contains(tui.render(), "first"))
+
+	tui.handleKey("down")
+	tui.handleKey("r")
+	assertEquals(t, "Resolved second", tui.status)
+	assertTrue(t, len(tui.entries) == 2)
+	assertEquals(t, "third", tui.selectedEntry().Title)
+
+	for _, key := range parseKeys([]byte("sin 5 days\r")) {
+		tui.handleKey(key)
+	}
+	assertTrue(t, strings.HasPrefix(tui.status, "Snoozed third until"))
+	third, _ := app.find("third")
+	assertTrue(t, third.Due.After(time.Now().Add(4*24*time.Hour)))
+
+	tui.handleKey("up")
+	for _, key := range parseKeys([]byte("dn\r")) {
+		tui.handleKey(key)
+	}
+	assertTrue(t, len(tui.entries) == 2)
+	for _, key := range parseKeys([]byte("dy\r")) {
+		tui.handleKey(key)
+	}
+	assertEquals(t, "Deleted first", tui.status)
+	assertTrue(t, len(tui.entries) == 1)
+
+	tui.handleKey("enter")
+	assertTrue(t, strings.Contains(tui.render(), "│ ["))
+	tui.handleKey("q")
+	assertTrue(t, tui.quit)
+}
+
+// countingApp counts the lookups of all todos, as done to render the blockers of a todo
+type countingApp struct {
+	app
+	findAllCalls int
+}
+
+func (a *countingApp) findAll(filter todoFilter) ([]todoModel, ShortIdMap) {
+	a.findAllCalls++
+	return a.app.findAll(filter)
+}
+
+func TestTui_rendersDetailsWithoutLookingUpBlockersEachTime(t *testing.T) {
+	local := newTestApp(t)
+	assertTrue(t, local.add(todoModel{Title: "blocker", Due: time.Now().Add(time.Hour)}) == nil)
+	assertTrue(t, local.add(todoModel{Title: "blocked", Due: time.Now().Add(2 * time.Hour)}) == nil)
+	blocker, _ := local.find("blocker")
+	blocked, _ := local.find("blocked")
+	assertTrue(t, local.block(blocked.Id, blocker.Id) == nil)
+	app := &countingApp{app: local}
+	tui := newTui(&cli{app: app, timeRenderLayout: time.RFC1123, location: time.UTC}, todoFilter{}, SortModeDue)
+	tui.refresh()
+	tui.handleKey("down")
+	tui.handleKey("enter")
+
+	assertTrue(t, strings.Contains(tui.render(), "Blocked by"))
+	calls := app.findAllCalls
+	tui.render()
+	tui.render()
+	assertTrue(t, app.findAllCalls == calls)
+
+	tui.refresh()
+	assertTrue(t, strings.Contains(tui.render(), "Blocked by"))
+	assertTrue(t, app.findAllCalls == calls+2)
+}